@@ -0,0 +1,286 @@
+// Command statusctl queries StatusService of one or more indexers.
+//
+// Exit codes: 0 if every network is synced, 1 if some network is not synced or a server reports no network (or not
+// the one given with -network), 2 if a server couldn't be queried.
+//
+//	statusctl -addr grpc://indexer-0:27000 -addr http://indexer-1:8080 -threshold 1m
+//	statusctl -addr grpcs://indexer.example.com:443 -ca ca.pem -token "$STATUS_TOKEN"
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/proxima-one/indexer-utils-go/v2/pkg/consume_status/client"
+	"github.com/proxima-one/indexer-utils-go/v2/pkg/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+const (
+	exitSynced    = 0
+	exitNotSynced = 1
+	exitError     = 2
+)
+
+type addresses []string
+
+func (a *addresses) String() string {
+	return strings.Join(*a, ",")
+}
+
+func (a *addresses) Set(value string) error {
+	*a = append(*a, value)
+	return nil
+}
+
+type serverStatus struct {
+	Address string         `json:"address"`
+	Status  *client.Status `json:"status,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+func main() {
+	os.Exit(run())
+}
+
+// run returns the exit code, so the deferred calls run before exiting
+func run() int {
+	var addrs addresses
+	flag.Var(&addrs, "addr", "status server address, grpc://host:port (default scheme), grpcs://host:port or http(s)://host:port. Repeatable")
+	output := flag.String("o", "table", "output format: table or json")
+	network := flag.String("network", "", "show only this network")
+	streams := flag.Bool("streams", false, "show streams of every network in the table")
+	threshold := flag.Duration("threshold", 0, "max time lag of a synced network. The server's sync threshold is used if zero")
+	watch := flag.Duration("watch", 0, "refresh every interval until interrupted")
+	timeout := flag.Duration("timeout", 10*time.Second, "timeout of a single query")
+	token := flag.String("token", "", "token sent as \"Authorization: Bearer <token>\"")
+	caFile := flag.String("ca", "", "PEM file with CA certificates verifying grpcs:// and https:// servers. System roots are used if empty")
+	flag.Parse()
+
+	if len(addrs) == 0 {
+		fmt.Fprintln(os.Stderr, "at least one -addr is required")
+		flag.Usage()
+		return exitError
+	}
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(os.Stderr, "unknown output format %q\n", *output)
+		return exitError
+	}
+
+	tlsConfig, err := newTLSConfig(*caFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	clients := make([]*client.Client, len(addrs))
+	for i, addr := range addrs {
+		c, err := newClient(ctx, addr, *token, tlsConfig)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", addr, err)
+			return exitError
+		}
+		defer c.Close()
+		clients[i] = c
+	}
+
+	code := exitSynced
+	for {
+		statuses := query(ctx, addrs, clients, *timeout, *network)
+		code = exitCode(statuses, *threshold)
+		if *watch > 0 && *output == "table" {
+			fmt.Print("\033[H\033[2J")
+			fmt.Println(time.Now().Format("2006-01-02 15:04:05"))
+		}
+		render(statuses, *output, *threshold, *streams)
+
+		if *watch == 0 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return code
+		case <-time.After(*watch):
+		}
+	}
+	return code
+}
+
+func newClient(ctx context.Context, addr string, token string, tlsConfig *tls.Config) (*client.Client, error) {
+	if strings.HasPrefix(addr, "http://") || strings.HasPrefix(addr, "https://") {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		var roundTripper http.RoundTripper = transport
+		if token != "" {
+			roundTripper = &tokenTransport{token: token, next: transport}
+		}
+		return client.NewHTTP(addr, &http.Client{Transport: roundTripper}), nil
+	}
+
+	var opts []grpc.DialOption
+	if strings.HasPrefix(addr, "grpcs://") {
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	}
+	if token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(tokenCredentials(token)))
+	}
+	address := strings.TrimPrefix(strings.TrimPrefix(addr, "grpc://"), "grpcs://")
+	return client.DialGRPC(ctx, address, opts...)
+}
+
+// newTLSConfig trusts certificates in caFile, or the system roots if caFile is empty
+func newTLSConfig(caFile string) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile == "" {
+		return config, nil
+	}
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+	config.RootCAs = x509.NewCertPool()
+	if !config.RootCAs.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", caFile)
+	}
+	return config, nil
+}
+
+// tokenCredentials sends the token with every gRPC call. It is allowed over grpc:// too, as the servers may sit
+// behind a TLS-terminating proxy.
+type tokenCredentials string
+
+func (t tokenCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(t)}, nil
+}
+
+func (t tokenCredentials) RequireTransportSecurity() bool {
+	return false
+}
+
+// tokenTransport sends the token with every HTTP request
+type tokenTransport struct {
+	token string
+	next  http.RoundTripper
+}
+
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return t.next.RoundTrip(req)
+}
+
+func query(ctx context.Context, addrs []string, clients []*client.Client, timeout time.Duration, network string) []serverStatus {
+	res := make([]serverStatus, len(clients))
+	for i, c := range clients {
+		res[i].Address = addrs[i]
+		queryCtx, cancel := context.WithTimeout(ctx, timeout)
+		status, err := c.GetStatus(queryCtx)
+		cancel()
+		if err != nil {
+			res[i].Error = err.Error()
+			continue
+		}
+		if network != "" {
+			status.Networks = utils.FilterArray(status.Networks, func(s client.NetworkStatus) bool {
+				return s.Network == network
+			})
+		}
+		res[i].Status = status
+	}
+	return res
+}
+
+func exitCode(statuses []serverStatus, threshold time.Duration) int {
+	code := exitSynced
+	for _, status := range statuses {
+		if status.Status == nil {
+			return exitError
+		}
+		if len(status.Status.Networks) == 0 {
+			code = exitNotSynced
+		}
+		for _, networkStatus := range status.Status.Networks {
+			if !client.IsSynced(networkStatus, threshold) {
+				code = exitNotSynced
+			}
+		}
+	}
+	return code
+}
+
+func render(statuses []serverStatus, output string, threshold time.Duration, streams bool) {
+	if output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		utils.PanicOnError(enc.Encode(statuses))
+		return
+	}
+
+	t := table.NewWriter()
+	t.SetStyle(table.StyleRounded)
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Server", "Network", "Height", "Timestamp", "Lag", "Behind", "Synced", "State", "Streams", "Aggregation"})
+	for _, status := range statuses {
+		if status.Status == nil {
+			t.AppendRow(table.Row{status.Address, "error: " + status.Error})
+			continue
+		}
+		for _, network := range status.Status.Networks {
+			synced := utils.SprintOne(client.IsSynced(network, threshold))
+			if network.Status.Stale {
+				synced += " (stale)"
+			}
+			row := append(table.Row{status.Address, network.Network}, statusCells(network.Status)...)
+			t.AppendRow(append(row, synced, network.State, network.StreamCount, network.Aggregation))
+
+			if !streams {
+				continue
+			}
+			for _, stream := range network.Streams {
+				row := table.Row{"", "  " + stream.StreamId}
+				if stream.Status != nil {
+					row = append(row, statusCells(*stream.Status)...)
+				} else {
+					row = append(row, statusCells(client.IndexingStatus{})...)
+				}
+				state := string(stream.State)
+				if stream.LastError != "" {
+					state += ": " + stream.LastError
+				}
+				t.AppendRow(append(row, "", state))
+			}
+		}
+	}
+	t.Render()
+}
+
+// statusCells returns the Height, Timestamp, Lag and Behind cells
+func statusCells(status client.IndexingStatus) table.Row {
+	if status.Timestamp.IsZero() {
+		return table.Row{"-", "-", "-", "-"}
+	}
+	behind := "-"
+	if status.BlocksBehind != nil {
+		behind = utils.SprintOne(*status.BlocksBehind)
+	}
+	return table.Row{
+		status.BlockNumber,
+		status.Timestamp.Format("2006-01-02 15:04:05"),
+		status.TimeLag.Truncate(time.Second).String(),
+		behind,
+	}
+}
@@ -0,0 +1,109 @@
+package consume_status
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	pb "github.com/proxima-one/indexer-utils-go/v2/pkg/consume_status/internal/proto"
+	"github.com/proxima-one/indexer-utils-go/v2/pkg/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type AdminCommandType int
+
+const (
+	AdminPause AdminCommandType = iota
+	AdminResume
+	// AdminRewind asks to continue consumption from AdminCommand.Offset
+	AdminRewind
+)
+
+func (t AdminCommandType) String() string {
+	switch t {
+	case AdminPause:
+		return "pause"
+	case AdminResume:
+		return "resume"
+	case AdminRewind:
+		return "rewind"
+	}
+	return fmt.Sprintf("AdminCommandType(%d)", int(t))
+}
+
+type AdminCommand struct {
+	Type     AdminCommandType
+	StreamId string
+	// Offset is set for AdminRewind only
+	Offset string
+}
+
+// AdminCommandHandler applies a command to the stream consumption. A returned error is sent to the caller.
+type AdminCommandHandler func(cmd AdminCommand) error
+
+// AuthFunc authorizes an admin call. The incoming gRPC metadata of ctx holds the request credentials,
+// for gateway calls the Authorization header is forwarded as "authorization".
+// Errors without a gRPC status are reported as PermissionDenied.
+type AuthFunc func(ctx context.Context) error
+
+type adminServer struct {
+	s    *ConsumeStatusServer
+	auth AuthFunc
+}
+
+// EnableAdmin serves AdminService, which lets operators pause, resume and rewind streams handled with
+// HandleAdminCommands. Every call is checked with auth, which is required.
+func (s *ConsumeStatusServer) EnableAdmin(auth AuthFunc) *ConsumeStatusServer {
+	if auth == nil {
+		utils.PanicOnError(errors.New("admin service requires an auth func"))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.admin = &adminServer{s: s, auth: auth}
+	return s
+}
+
+// HandleAdminCommands subscribes handler to admin commands for the stream, replacing the previous handler.
+// Commands for streams without a handler are rejected.
+func (s *ConsumeStatusServer) HandleAdminCommands(streamId string, handler AdminCommandHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.adminHandlerByStreamId[streamId] = handler
+}
+
+func (a *adminServer) Pause(ctx context.Context, req *pb.PauseRequest) (*emptypb.Empty, error) {
+	return a.handle(ctx, AdminCommand{Type: AdminPause, StreamId: req.StreamId})
+}
+
+func (a *adminServer) Resume(ctx context.Context, req *pb.ResumeRequest) (*emptypb.Empty, error) {
+	return a.handle(ctx, AdminCommand{Type: AdminResume, StreamId: req.StreamId})
+}
+
+func (a *adminServer) RequestRewind(ctx context.Context, req *pb.RequestRewindRequest) (*emptypb.Empty, error) {
+	if req.Offset == "" {
+		return nil, status.Error(codes.InvalidArgument, "offset is required")
+	}
+	return a.handle(ctx, AdminCommand{Type: AdminRewind, StreamId: req.StreamId, Offset: req.Offset})
+}
+
+func (a *adminServer) handle(ctx context.Context, cmd AdminCommand) (*emptypb.Empty, error) {
+	if err := a.auth(ctx); err != nil {
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	a.s.mu.RLock()
+	handler, ok := a.s.adminHandlerByStreamId[cmd.StreamId]
+	a.s.mu.RUnlock()
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "stream %s doesn't handle admin commands", cmd.StreamId)
+	}
+	if err := handler(cmd); err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "failed to %s stream %s: %v", cmd.Type, cmd.StreamId, err)
+	}
+	return &emptypb.Empty{}, nil
+}
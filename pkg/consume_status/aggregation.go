@@ -0,0 +1,81 @@
+package consume_status
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/proxima-one/indexer-utils-go/v2/pkg/utils"
+)
+
+// NetworkAggregation reduces statuses of all streams of a network to a single network status.
+type NetworkAggregation interface {
+	// Name is reported in NetworkIndexingStatus.aggregation.
+	Name() string
+	// Aggregate is called with at least one status.
+	Aggregate(statuses []IndexingStatus) IndexingStatus
+}
+
+type aggregation struct {
+	name      string
+	aggregate func(sorted []IndexingStatus) IndexingStatus
+}
+
+func (a *aggregation) Name() string {
+	return a.name
+}
+
+// Aggregate sorts statuses by block number before passing them to the strategy.
+func (a *aggregation) Aggregate(statuses []IndexingStatus) IndexingStatus {
+	sorted := make([]IndexingStatus, len(statuses))
+	copy(sorted, statuses)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return utils.MustConvStrToInt64(sorted[i].BlockNumber) < utils.MustConvStrToInt64(sorted[j].BlockNumber)
+	})
+	return a.aggregate(sorted)
+}
+
+// MinAggregation reports the stream with the lowest block number. It is the default strategy.
+func MinAggregation() NetworkAggregation {
+	return &aggregation{
+		name: "min",
+		aggregate: func(sorted []IndexingStatus) IndexingStatus {
+			return sorted[0]
+		},
+	}
+}
+
+// MaxAggregation reports the stream with the highest block number. Useful for redundant streams.
+func MaxAggregation() NetworkAggregation {
+	return &aggregation{
+		name: "max",
+		aggregate: func(sorted []IndexingStatus) IndexingStatus {
+			return sorted[len(sorted)-1]
+		},
+	}
+}
+
+// MedianAggregation reports the stream with the median block number. For an even number of streams
+// the lower of the two middle streams is reported.
+func MedianAggregation() NetworkAggregation {
+	return &aggregation{
+		name: "median",
+		aggregate: func(sorted []IndexingStatus) IndexingStatus {
+			return sorted[(len(sorted)-1)/2]
+		},
+	}
+}
+
+// WithinBlocksAggregation reports the highest stream while all streams are within maxSpread blocks of it,
+// otherwise the lowest one.
+func WithinBlocksAggregation(maxSpread int64) NetworkAggregation {
+	return &aggregation{
+		name: fmt.Sprintf("within_blocks(%d)", maxSpread),
+		aggregate: func(sorted []IndexingStatus) IndexingStatus {
+			lowest, highest := sorted[0], sorted[len(sorted)-1]
+			if utils.MustConvStrToInt64(highest.BlockNumber)-utils.MustConvStrToInt64(lowest.BlockNumber) <= maxSpread {
+				return highest
+			}
+			return lowest
+		},
+	}
+}
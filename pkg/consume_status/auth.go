@@ -0,0 +1,115 @@
+package consume_status
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/proxima-one/indexer-utils-go/v2/pkg/grpc_gateway"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const apiKeyHeader = "X-Api-Key"
+
+// TokenAuth accepts requests with "Authorization: Bearer <token>" or "X-Api-Key: <token>" for one of tokens.
+func TokenAuth(tokens ...string) AuthFunc {
+	return func(ctx context.Context) error {
+		md, _ := metadata.FromIncomingContext(ctx)
+		var presented []string
+		for _, value := range md.Get("authorization") {
+			if strings.HasPrefix(value, "Bearer ") {
+				presented = append(presented, strings.TrimPrefix(value, "Bearer "))
+			}
+		}
+		presented = append(presented, md.Get(strings.ToLower(apiKeyHeader))...)
+
+		for _, token := range tokens {
+			for _, p := range presented {
+				if subtle.ConstantTimeCompare([]byte(p), []byte(token)) == 1 {
+					return nil
+				}
+			}
+		}
+		if len(presented) == 0 {
+			return status.Error(codes.Unauthenticated, "missing bearer token or API key")
+		}
+		return status.Error(codes.Unauthenticated, "invalid bearer token or API key")
+	}
+}
+
+// WithAuth checks every gRPC call and every gateway request under /api with auth.
+// Health checks, /healthz, /readyz, the schema and the OpenAPI UI are not checked.
+func (s *ConsumeStatusServer) WithAuth(auth AuthFunc) *ConsumeStatusServer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.auth = auth
+	return s
+}
+
+func (s *ConsumeStatusServer) authenticate(ctx context.Context, fullMethod string) error {
+	s.mu.RLock()
+	auth := s.auth
+	s.mu.RUnlock()
+	if auth == nil || strings.HasPrefix(fullMethod, "/"+grpc_health_v1.Health_ServiceDesc.ServiceName+"/") {
+		return nil
+	}
+	err := auth(ctx)
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Error(codes.Unauthenticated, err.Error())
+}
+
+func (s *ConsumeStatusServer) unaryAuthInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+
+	if err := s.authenticate(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *ConsumeStatusServer) streamAuthInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+
+	if err := s.authenticate(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// authMiddleware checks REST calls, which the gateway serves in-process, bypassing the auth interceptors.
+// It runs on /api requests with the base path stripped, after CORS preflights are answered.
+func (s *ConsumeStatusServer) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		md := metadata.MD{}
+		if value := r.Header.Get("Authorization"); value != "" {
+			md.Set("authorization", value)
+		}
+		if value := r.Header.Get(apiKeyHeader); value != "" {
+			md.Set(strings.ToLower(apiKeyHeader), value)
+		}
+		if err := s.authenticate(metadata.NewIncomingContext(r.Context(), md), r.URL.Path); err != nil {
+			grpc_gateway.WriteError(w, r, err)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// forwardAPIKey makes the gateway pass the API key header to the gRPC server, in addition to the default headers
+func forwardAPIKey(key string) (string, bool) {
+	if strings.EqualFold(key, apiKeyHeader) {
+		return strings.ToLower(apiKeyHeader), true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
@@ -0,0 +1,66 @@
+package consume_status_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/proxima-one/indexer-utils-go/v2/pkg/consume_status"
+	statusv2 "github.com/proxima-one/indexer-utils-go/v2/pkg/consume_status/proto/v2"
+	"github.com/proxima-one/indexer-utils-go/v2/pkg/consume_status/statustest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestWithAuth(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := consume_status.NewConsumeStatusServer().WithAuth(consume_status.TokenAuth("secret"))
+	grpcClient, httpClient, err := statustest.Start(ctx, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("gRPC", func(t *testing.T) {
+		if _, err := grpcClient.GetStatus(ctx, &statusv2.GetStatusRequest{}); status.Code(err) != codes.Unauthenticated {
+			t.Errorf("without token: err = %v, want Unauthenticated", err)
+		}
+		authorized := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer secret")
+		if _, err := grpcClient.GetStatus(authorized, &statusv2.GetStatusRequest{}); err != nil {
+			t.Errorf("with token: %v", err)
+		}
+	})
+
+	t.Run("HTTP", func(t *testing.T) {
+		cases := []struct {
+			path   string
+			token  string
+			status int
+		}{
+			{"/api/get_status", "", http.StatusUnauthorized},
+			{"/api/get_status", "wrong", http.StatusUnauthorized},
+			{"/api/get_status", "secret", http.StatusOK},
+			{"/proto/schema.json", "", http.StatusOK},
+			{"/healthz", "", http.StatusOK},
+		}
+		for _, c := range cases {
+			req, err := http.NewRequest(http.MethodGet, statustest.BaseURL+c.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.token != "" {
+				req.Header.Set("Authorization", "Bearer "+c.token)
+			}
+			res, err := httpClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+			if res.StatusCode != c.status {
+				t.Errorf("GET %s with token %q: status %d, want %d", c.path, c.token, res.StatusCode, c.status)
+			}
+		}
+	})
+}
@@ -0,0 +1,173 @@
+// Package client queries StatusService of a ConsumeStatusServer over gRPC or the REST gateway.
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	pb "github.com/proxima-one/indexer-utils-go/v2/pkg/consume_status/internal/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const defaultPollInterval = time.Second
+
+// ErrNetworkNotFound is returned when the server doesn't report the requested network.
+var ErrNetworkNotFound = errors.New("network not found")
+
+type Client struct {
+	getStatus    func(ctx context.Context) (*pb.GetStatusResponse, error)
+	close        func() error
+	pollInterval time.Duration
+}
+
+// NewGRPC creates a client using an existing connection. Closing the client doesn't close the connection.
+func NewGRPC(conn grpc.ClientConnInterface) *Client {
+	grpcClient := pb.NewStatusServiceClient(conn)
+	return &Client{
+		getStatus: func(ctx context.Context) (*pb.GetStatusResponse, error) {
+			return grpcClient.GetStatus(ctx, &emptypb.Empty{})
+		},
+		close:        func() error { return nil },
+		pollInterval: defaultPollInterval,
+	}
+}
+
+// DialGRPC connects to the gRPC server at address. Insecure credentials are used unless overridden by opts.
+func DialGRPC(ctx context.Context, address string, opts ...grpc.DialOption) (*Client, error) {
+	conn, err := grpc.DialContext(
+		ctx,
+		address,
+		append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, opts...)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial server: %w", err)
+	}
+	c := NewGRPC(conn)
+	c.close = conn.Close
+	return c, nil
+}
+
+// NewHTTP creates a client for the REST gateway at baseURL, e.g. "http://indexer:8080".
+// http.DefaultClient is used if httpClient is nil.
+func NewHTTP(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	url := strings.TrimSuffix(baseURL, "/") + "/api/get_status"
+	return &Client{
+		getStatus: func(ctx context.Context) (*pb.GetStatusResponse, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := httpClient.Do(req)
+			if err != nil {
+				return nil, err
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				return nil, err
+			}
+			if resp.StatusCode != http.StatusOK {
+				return nil, fmt.Errorf("unexpected response %s: %s", resp.Status, body)
+			}
+			res := new(pb.GetStatusResponse)
+			if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(body, res); err != nil {
+				return nil, fmt.Errorf("failed to parse response: %w", err)
+			}
+			return res, nil
+		},
+		close:        func() error { return nil },
+		pollInterval: defaultPollInterval,
+	}
+}
+
+// WithPollInterval sets how often Wait* methods query the server. One second by default.
+func (c *Client) WithPollInterval(interval time.Duration) *Client {
+	c.pollInterval = interval
+	return c
+}
+
+func (c *Client) Close() error {
+	return c.close()
+}
+
+func (c *Client) GetStatus(ctx context.Context) (*Status, error) {
+	res, err := c.getStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return statusFromProto(res), nil
+}
+
+// GetNetworkStatus returns ErrNetworkNotFound if the server doesn't report the network.
+func (c *Client) GetNetworkStatus(ctx context.Context, network string) (NetworkStatus, error) {
+	status, err := c.GetStatus(ctx)
+	if err != nil {
+		return NetworkStatus{}, err
+	}
+	networkStatus, ok := status.Network(network)
+	if !ok {
+		return NetworkStatus{}, fmt.Errorf("%w: %s", ErrNetworkNotFound, network)
+	}
+	return networkStatus, nil
+}
+
+// WaitUntil polls the server until cond returns true or ctx is done. Failed requests are retried.
+func (c *Client) WaitUntil(ctx context.Context, cond func(*Status) bool) (*Status, error) {
+	t := time.NewTicker(c.pollInterval)
+	defer t.Stop()
+	var lastErr error
+	for {
+		status, err := c.GetStatus(ctx)
+		if err == nil && cond(status) {
+			return status, nil
+		}
+		lastErr = err
+
+		select {
+		case <-ctx.Done():
+			if lastErr != nil {
+				return nil, fmt.Errorf("%w, last error: %v", ctx.Err(), lastErr)
+			}
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// WaitUntilSynced blocks until the time lag of the network is within threshold.
+// If threshold is zero, the sync threshold configured on the server is used.
+func (c *Client) WaitUntilSynced(ctx context.Context, network string, threshold time.Duration) (NetworkStatus, error) {
+	var res NetworkStatus
+	_, err := c.WaitUntil(ctx, func(status *Status) bool {
+		networkStatus, ok := status.Network(network)
+		if !ok {
+			return false
+		}
+		res = networkStatus
+		return IsSynced(networkStatus, threshold)
+	})
+	return res, err
+}
+
+// IsSynced checks the time lag of the network against threshold, or uses is_synced reported by the server
+// if threshold is zero. A network without a status is never synced.
+func IsSynced(status NetworkStatus, threshold time.Duration) bool {
+	if status.Status.Timestamp.IsZero() {
+		return false
+	}
+	if threshold == 0 {
+		return status.Status.IsSynced
+	}
+	return status.Status.TimeLag <= threshold
+}
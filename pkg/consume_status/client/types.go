@@ -0,0 +1,150 @@
+package client
+
+import (
+	"time"
+
+	pb "github.com/proxima-one/indexer-utils-go/v2/pkg/consume_status/internal/proto"
+)
+
+type IndexingStatus struct {
+	Timestamp   time.Time `json:"timestamp"`
+	BlockNumber string    `json:"block_number"`
+	// HeadTimestamp and HeadBlockNumber are set if the server knows the chain head
+	HeadTimestamp   *time.Time    `json:"head_timestamp,omitempty"`
+	HeadBlockNumber *string       `json:"head_block_number,omitempty"`
+	BlocksBehind    *int64        `json:"blocks_behind,omitempty"`
+	TimeLag         time.Duration `json:"time_lag"` // nanoseconds in JSON
+	IsSynced        bool          `json:"is_synced"`
+	Stale           bool          `json:"stale"`
+}
+
+// State is one of "OK", "DEGRADED" or "FAILING"
+type State string
+
+const (
+	StateOK       State = "OK"
+	StateDegraded State = "DEGRADED"
+	StateFailing  State = "FAILING"
+)
+
+type NetworkStatus struct {
+	Network     string         `json:"network"`
+	Status      IndexingStatus `json:"status"`
+	Aggregation string         `json:"aggregation"`
+	StreamCount int            `json:"stream_count"`
+	State       State          `json:"state"`
+	Streams     []StreamStatus `json:"streams"`
+}
+
+type StreamStatus struct {
+	StreamId string `json:"stream_id"`
+	// Status is nil until the stream reports its first status
+	Status        *IndexingStatus `json:"status,omitempty"`
+	State         State           `json:"state"`
+	LastError     string          `json:"last_error,omitempty"`
+	LastErrorTime time.Time       `json:"last_error_time"`
+	ErrorCount    int             `json:"error_count"`
+}
+
+type UpstreamStatus struct {
+	Address   string    `json:"address"`
+	Reachable bool      `json:"reachable"`
+	LastSeen  time.Time `json:"last_seen"`
+	Error     string    `json:"error,omitempty"`
+}
+
+type Status struct {
+	Networks  []NetworkStatus  `json:"networks"`
+	Upstreams []UpstreamStatus `json:"upstreams"`
+}
+
+// Network returns status of the network, if the server reports it.
+func (s *Status) Network(network string) (NetworkStatus, bool) {
+	for _, status := range s.Networks {
+		if status.Network == network {
+			return status, true
+		}
+	}
+	return NetworkStatus{}, false
+}
+
+func statusFromProto(res *pb.GetStatusResponse) *Status {
+	status := &Status{
+		Networks:  make([]NetworkStatus, 0, len(res.Networks)),
+		Upstreams: make([]UpstreamStatus, 0, len(res.Upstreams)),
+	}
+	for _, network := range res.Networks {
+		networkStatus := NetworkStatus{
+			Network:     network.Network,
+			Status:      indexingStatusFromProto(network.Status),
+			Aggregation: network.Aggregation,
+			StreamCount: int(network.StreamCount),
+			State:       stateFromProto(network.State),
+			Streams:     make([]StreamStatus, 0, len(network.Streams)),
+		}
+		for _, stream := range network.Streams {
+			streamStatus := StreamStatus{
+				StreamId:   stream.StreamId,
+				State:      stateFromProto(stream.State),
+				LastError:  stream.GetLastError(),
+				ErrorCount: int(stream.ErrorCount),
+			}
+			if stream.Status != nil {
+				indexingStatus := indexingStatusFromProto(stream.Status)
+				streamStatus.Status = &indexingStatus
+			}
+			if stream.LastErrorTime != nil {
+				streamStatus.LastErrorTime = stream.LastErrorTime.AsTime()
+			}
+			networkStatus.Streams = append(networkStatus.Streams, streamStatus)
+		}
+		status.Networks = append(status.Networks, networkStatus)
+	}
+	for _, upstream := range res.Upstreams {
+		upstreamStatus := UpstreamStatus{
+			Address:   upstream.Address,
+			Reachable: upstream.Reachable,
+			Error:     upstream.GetError(),
+		}
+		if upstream.LastSeen != nil {
+			upstreamStatus.LastSeen = upstream.LastSeen.AsTime()
+		}
+		status.Upstreams = append(status.Upstreams, upstreamStatus)
+	}
+	return status
+}
+
+func indexingStatusFromProto(status *pb.IndexingStatus) IndexingStatus {
+	if status == nil {
+		return IndexingStatus{}
+	}
+	res := IndexingStatus{
+		BlockNumber:     status.GetBlockNumber(),
+		HeadBlockNumber: status.HeadBlockNumber,
+		BlocksBehind:    status.BlocksBehind,
+		IsSynced:        status.IsSynced,
+		Stale:           status.Stale,
+	}
+	if status.Timestamp != nil {
+		res.Timestamp = status.Timestamp.AsTime()
+	}
+	if status.HeadTimestamp != nil {
+		headTimestamp := status.HeadTimestamp.AsTime()
+		res.HeadTimestamp = &headTimestamp
+	}
+	if status.TimeLag != nil {
+		res.TimeLag = status.TimeLag.AsDuration()
+	}
+	return res
+}
+
+// stateFromProto treats unknown states, e.g. of older servers, as OK
+func stateFromProto(state pb.State) State {
+	switch state {
+	case pb.State_STATE_DEGRADED:
+		return StateDegraded
+	case pb.State_STATE_FAILING:
+		return StateFailing
+	}
+	return StateOK
+}
@@ -2,64 +2,344 @@ package consume_status
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	pb "github.com/proxima-one/indexer-utils-go/v2/pkg/consume_status/internal/proto"
+	statusv2 "github.com/proxima-one/indexer-utils-go/v2/pkg/consume_status/proto/v2"
 	"github.com/proxima-one/indexer-utils-go/v2/pkg/grpc_gateway"
 	"github.com/proxima-one/indexer-utils-go/v2/pkg/utils"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/emptypb"
 	"google.golang.org/protobuf/types/known/timestamppb"
+	"io/fs"
 	"log"
 	"net"
+	"net/http"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
 
-type indexingStatus struct {
+const (
+	protoFileName         = "indexing_status.swagger.json"
+	adminProtoFileName    = "admin.swagger.json"
+	statusV2ProtoFileName = "status.swagger.json"
+)
+
+type IndexingStatus struct {
 	Timestamp   time.Time
 	BlockNumber string
 }
 
 type ConsumeStatusServer struct {
-	mu                sync.RWMutex
-	statusByStreamId  map[string]indexingStatus
-	networkByStreamId map[string]string
+	mu                   sync.RWMutex
+	statusByStreamId     map[string]IndexingStatus
+	networkByStreamId    map[string]string
+	labelsByStreamId     map[string]map[string]string
+	aggregationByNetwork map[string]NetworkAggregation
+	defaultAggregation   NetworkAggregation
+
+	headByNetwork          map[string]IndexingStatus
+	syncThresholdByNetwork map[string]SyncThreshold
+	defaultSyncThreshold   SyncThreshold
+
+	updatedAtByStreamId map[string]time.Time
+	stallTimeout        time.Duration
+	staleStreamIds      map[string]struct{}
+	errorByStreamId     map[string]streamError
+	// restoredByStreamId holds snapshot entries of streams that are not registered yet
+	restoredByStreamId map[string]snapshotStream
+
+	history   *statusHistory
+	upstreams []*upstreamState
+
+	admin                  *adminServer
+	adminHandlerByStreamId map[string]AdminCommandHandler
+
+	tls  *tlsReloader
+	auth AuthFunc
+
+	grpcOptions         grpcServerOptions
+	extraGatewayOptions []grpc_gateway.Option
+
+	backgroundTasks []func(ctx context.Context)
+	backgroundOnce  sync.Once
 }
 
 func NewConsumeStatusServer() *ConsumeStatusServer {
 	return &ConsumeStatusServer{
-		statusByStreamId:  make(map[string]indexingStatus),
-		networkByStreamId: make(map[string]string),
+		statusByStreamId:     make(map[string]IndexingStatus),
+		networkByStreamId:    make(map[string]string),
+		labelsByStreamId:     make(map[string]map[string]string),
+		aggregationByNetwork: make(map[string]NetworkAggregation),
+		defaultAggregation:   MinAggregation(),
+
+		headByNetwork:          make(map[string]IndexingStatus),
+		syncThresholdByNetwork: make(map[string]SyncThreshold),
+		defaultSyncThreshold:   DefaultSyncThreshold,
+
+		updatedAtByStreamId: make(map[string]time.Time),
+		staleStreamIds:      make(map[string]struct{}),
+		restoredByStreamId:  make(map[string]snapshotStream),
+		errorByStreamId:     make(map[string]streamError),
+
+		adminHandlerByStreamId: make(map[string]AdminCommandHandler),
 	}
 }
 
+// WithDefaultAggregation sets the strategy used for networks without a specific one. MinAggregation by default.
+func (s *ConsumeStatusServer) WithDefaultAggregation(aggregation NetworkAggregation) *ConsumeStatusServer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.defaultAggregation = aggregation
+	return s
+}
+
+// WithNetworkAggregation sets the strategy used to compute the status of the network.
+func (s *ConsumeStatusServer) WithNetworkAggregation(network string, aggregation NetworkAggregation) *ConsumeStatusServer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.aggregationByNetwork[network] = aggregation
+	return s
+}
+
 func (s *ConsumeStatusServer) Start(ctx context.Context, grpcPort, httpPort int) {
-	grpcServer := grpc.NewServer()
-	pb.RegisterStatusServiceServer(grpcServer, s)
+	grpcLis, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%d", grpcPort))
+	if err != nil {
+		log.Fatalf("failed to listen: %v", err)
+	}
+	httpLis, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%d", httpPort))
+	if err != nil {
+		log.Fatalf("failed to listen: %v", err)
+	}
+	s.Serve(ctx, grpcLis, httpLis)
+}
+
+// Serve serves gRPC and the REST gateway on caller-supplied listeners, e.g. unix sockets or bufconn.
+// Both servers stop when ctx is done.
+func (s *ConsumeStatusServer) Serve(ctx context.Context, grpcLis, httpLis net.Listener) {
+	s.startBackgroundTasks(ctx)
+	grpcServer := s.newGRPCServer(ctx, true)
+	httpServer := s.newHTTPServer(s.newHTTPHandler(ctx))
 
-	lis, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%d", grpcPort))
+	go func() {
+		logServeError(grpcServer.Serve(grpcLis))
+	}()
+	go func() {
+		logServeError(s.serveHTTP(httpServer, httpLis))
+	}()
+	go func() {
+		<-ctx.Done()
+		grpcServer.Stop()
+		_ = httpServer.Close()
+	}()
+}
+
+// StartSinglePort serves gRPC, the REST gateway, OpenAPI UI and the schema from one port.
+func (s *ConsumeStatusServer) StartSinglePort(ctx context.Context, port int) {
+	lis, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%d", port))
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
+	s.ServeSinglePort(ctx, lis)
+}
+
+// ServeSinglePort serves everything from one listener. HTTP/2 requests with a gRPC content type go to the gRPC
+// server, the rest go to the gateway. Without TLS, cleartext HTTP/2 (h2c) is accepted.
+func (s *ConsumeStatusServer) ServeSinglePort(ctx context.Context, lis net.Listener) {
+	s.startBackgroundTasks(ctx)
+	grpcServer := s.newGRPCServer(ctx, false)
+	httpHandler := s.newHTTPHandler(ctx)
+
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			grpcServer.ServeHTTP(w, r)
+			return
+		}
+		httpHandler.ServeHTTP(w, r)
+	})
+	if s.tlsReloader() == nil {
+		handler = h2c.NewHandler(handler, &http2.Server{})
+	}
+	httpServer := s.newHTTPServer(handler)
 
 	go func() {
-		log.Println(grpcServer.Serve(lis).Error())
+		logServeError(s.serveHTTP(httpServer, lis))
 	}()
 	go func() {
-		log.Println(grpc_gateway.Run(
-			ctx,
-			fmt.Sprintf("localhost:%d", grpcPort),
-			httpPort,
-			pb.ProtoDir,
-			"indexing_status.swagger.json",
-			pb.RegisterStatusServiceHandler,
-		).Error())
+		<-ctx.Done()
+		grpcServer.Stop()
+		_ = httpServer.Close()
 	}()
 }
 
+// startBackgroundTasks starts tasks configured by With* methods once, even if the server is served on several listeners
+func (s *ConsumeStatusServer) startBackgroundTasks(ctx context.Context) {
+	s.backgroundOnce.Do(func() {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		for _, task := range s.backgroundTasks {
+			go task(ctx)
+		}
+	})
+}
+
+// newGRPCServer creates a server with all services registered. Unless withTLS is false, it uses TLS if configured.
+func (s *ConsumeStatusServer) newGRPCServer(ctx context.Context, withTLS bool) *grpc.Server {
+	opts := s.grpcServerOptions()
+	if reloader := s.tlsReloader(); reloader != nil && withTLS {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(reloader.serverConfig())))
+	}
+
+	grpcServer := grpc.NewServer(opts...)
+	pb.RegisterStatusServiceServer(grpcServer, s)
+	statusv2.RegisterStatusServiceV2Server(grpcServer, &statusServerV2{s})
+	if admin := s.adminServer(); admin != nil {
+		pb.RegisterAdminServiceServer(grpcServer, admin)
+	}
+	grpc_health_v1.RegisterHealthServer(grpcServer, s.newHealthServer(ctx))
+	s.registerExtraServices(grpcServer)
+	return grpcServer
+}
+
+func (s *ConsumeStatusServer) newHTTPServer(handler http.Handler) *http.Server {
+	httpServer := &http.Server{Handler: handler}
+	if reloader := s.tlsReloader(); reloader != nil {
+		httpServer.TLSConfig = reloader.serverConfig()
+	}
+	return httpServer
+}
+
+func (s *ConsumeStatusServer) serveHTTP(httpServer *http.Server, lis net.Listener) error {
+	if httpServer.TLSConfig != nil {
+		return httpServer.ServeTLS(lis, "", "")
+	}
+	return httpServer.Serve(lis)
+}
+
+func (s *ConsumeStatusServer) tlsReloader() *tlsReloader {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tls
+}
+
+// gatewayOptions exposes v2 and optional services next to the v1 StatusService.
+func (s *ConsumeStatusServer) gatewayOptions() []grpc_gateway.Option {
+	opts := []grpc_gateway.Option{
+		grpc_gateway.WithServeMuxOptions(runtime.WithIncomingHeaderMatcher(forwardAPIKey)),
+		grpc_gateway.WithAPIMiddleware(s.authMiddleware),
+		grpc_gateway.WithLocalService(statusv2.ProtoDir, statusV2ProtoFileName, func(ctx context.Context, mux *runtime.ServeMux) error {
+			return statusv2.RegisterStatusServiceV2HandlerServer(ctx, mux, &statusServerV2{s})
+		}),
+	}
+	if admin := s.adminServer(); admin != nil {
+		opts = append(opts, grpc_gateway.WithLocalService(pb.ProtoDir, adminProtoFileName, func(ctx context.Context, mux *runtime.ServeMux) error {
+			return pb.RegisterAdminServiceHandlerServer(ctx, mux, admin)
+		}))
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append(opts, s.extraGatewayOptions...)
+}
+
+// WithGatewayService exposes a caller service through the gateway, merging its OpenAPI document into the schema.
+// registerHandlerServer should call the generated Register*HandlerServer function of the service.
+func (s *ConsumeStatusServer) WithGatewayService(protoFileFolder fs.FS, protoFileName string,
+	registerHandlerServer grpc_gateway.RegisterHandlerServerFunc) *ConsumeStatusServer {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.extraGatewayOptions = append(s.extraGatewayOptions,
+		grpc_gateway.WithLocalService(protoFileFolder, protoFileName, registerHandlerServer))
+	return s
+}
+
+// WithCORS allows browser pages on other origins to call the API and fetch the schema.
+func (s *ConsumeStatusServer) WithCORS(config grpc_gateway.CORSConfig) *ConsumeStatusServer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.extraGatewayOptions = append(s.extraGatewayOptions, grpc_gateway.WithCORS(config))
+	return s
+}
+
+// WithHTTPMiddleware wraps REST calls under /api, the HTTP counterpart of WithUnaryInterceptors.
+// The first middleware is the outermost.
+func (s *ConsumeStatusServer) WithHTTPMiddleware(middlewares ...grpc_gateway.Middleware) *ConsumeStatusServer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.extraGatewayOptions = append(s.extraGatewayOptions, grpc_gateway.WithAPIMiddleware(middlewares...))
+	return s
+}
+
+// WithGatewayOptions passes options to the gateway, e.g. grpc_gateway.WithMarshalOptions,
+// grpc_gateway.WithErrorHandler or grpc_gateway.WithMiddleware.
+func (s *ConsumeStatusServer) WithGatewayOptions(opts ...grpc_gateway.Option) *ConsumeStatusServer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.extraGatewayOptions = append(s.extraGatewayOptions, opts...)
+	return s
+}
+
+func (s *ConsumeStatusServer) adminServer() *adminServer {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.admin
+}
+
+// newHTTPHandler serves health endpoints and the gateway, which calls the services in-process.
+func (s *ConsumeStatusServer) newHTTPHandler(ctx context.Context) http.Handler {
+	gateway, err := grpc_gateway.NewLocalHandler(
+		ctx,
+		pb.ProtoDir,
+		protoFileName,
+		func(ctx context.Context, mux *runtime.ServeMux) error {
+			return pb.RegisterStatusServiceHandlerServer(ctx, mux, s)
+		},
+		s.gatewayOptions()...,
+	)
+	if err != nil {
+		log.Println(err.Error())
+		gateway = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "gateway is not available", http.StatusServiceUnavailable)
+		})
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.serveHealthz)
+	mux.HandleFunc("/readyz", s.serveReadyz)
+	mux.Handle("/", gateway)
+	return mux
+}
+
+// logServeError logs errors of Serve calls, except the ones caused by stopping the server
+func logServeError(err error) {
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Println(err.Error())
+	}
+}
+
 func (s *ConsumeStatusServer) RegisterStream(streamId, network string) {
+	s.RegisterStreamWithLabels(streamId, network, nil)
+}
+
+// RegisterStreamWithLabels registers a stream with metadata labels, which are reported by the v2 API
+func (s *ConsumeStatusServer) RegisterStreamWithLabels(streamId, network string, labels map[string]string) {
+	copied := make(map[string]string, len(labels))
+	for k, v := range labels {
+		copied[k] = v
+	}
+
 	s.mu.Lock()
 	s.networkByStreamId[streamId] = network
+	s.labelsByStreamId[streamId] = copied
+	s.applyRestoredStatus(streamId)
 	s.mu.Unlock()
 }
 
@@ -74,41 +354,171 @@ func (s *ConsumeStatusServer) UpdateStreamStatus(streamId string, timestamp time
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.statusByStreamId[streamId] = indexingStatus{
+	s.statusByStreamId[streamId] = IndexingStatus{
 		Timestamp:   timestamp,
 		BlockNumber: blockNumber,
 	}
+	s.updatedAtByStreamId[streamId] = time.Now()
+	delete(s.staleStreamIds, streamId)
 }
 
 func (s *ConsumeStatusServer) GetStatus(_ context.Context, _ *emptypb.Empty) (*pb.GetStatusResponse, error) {
 	s.mu.RLock()
-	networkStatuses := make(map[string]*indexingStatus)
-	for streamId, streamStatus := range s.statusByStreamId {
-		network := s.networkByStreamId[streamId]
-		if _, ok := networkStatuses[network]; !ok {
-			networkStatuses[network] = &indexingStatus{
-				Timestamp:   streamStatus.Timestamp,
-				BlockNumber: streamStatus.BlockNumber,
+	res := &pb.GetStatusResponse{
+		Networks:  make([]*pb.NetworkIndexingStatus, 0),
+		Upstreams: s.upstreamsToProto(),
+	}
+	s.mu.RUnlock()
+	for _, status := range s.networkStatuses() {
+		networkStatus := &pb.NetworkIndexingStatus{
+			Network:     status.Network,
+			Aggregation: status.Aggregation,
+			StreamCount: uint32(status.StreamCount),
+			State:       status.State.toProto(),
+			Streams:     make([]*pb.StreamIndexingStatus, 0, len(status.Streams)),
+		}
+		if status.Status != nil {
+			networkStatus.Status = status.Status.toProto()
+		}
+		for _, stream := range status.Streams {
+			streamStatus := &pb.StreamIndexingStatus{
+				StreamId:   stream.StreamId,
+				State:      stream.State.toProto(),
+				ErrorCount: uint32(stream.ErrorCount),
 			}
-		} else {
-			networkStatus := networkStatuses[network]
-			if utils.MustConvStrToInt64(streamStatus.BlockNumber) < utils.MustConvStrToInt64(networkStatus.BlockNumber) {
-				networkStatus.Timestamp = streamStatus.Timestamp
-				networkStatus.BlockNumber = streamStatus.BlockNumber
+			if stream.Status != nil {
+				streamStatus.Status = stream.Status.toProto()
 			}
+			if stream.LastError != nil {
+				lastError := stream.LastError.Error()
+				streamStatus.LastError = &lastError
+			}
+			if !stream.LastErrorAt.IsZero() {
+				streamStatus.LastErrorTime = timestamppb.New(stream.LastErrorAt)
+			}
+			networkStatus.Streams = append(networkStatus.Streams, streamStatus)
+		}
+		res.Networks = append(res.Networks, networkStatus)
+	}
+	return res, nil
+}
+
+// lagStatus is an indexing status compared to the chain head
+type lagStatus struct {
+	IndexingStatus
+	Head         *IndexingStatus
+	BlocksBehind *int64
+	TimeLag      time.Duration
+	IsSynced     bool
+	Stale        bool
+}
+
+func (s *lagStatus) toProto() *pb.IndexingStatus {
+	res := &pb.IndexingStatus{
+		Timestamp:    timestamppb.New(s.Timestamp),
+		BlockNumber:  &s.BlockNumber,
+		BlocksBehind: s.BlocksBehind,
+		TimeLag:      durationpb.New(s.TimeLag),
+		IsSynced:     s.IsSynced,
+		Stale:        s.Stale,
+	}
+	if s.Head != nil {
+		res.HeadBlockNumber = &s.Head.BlockNumber
+		res.HeadTimestamp = timestamppb.New(s.Head.Timestamp)
+	}
+	return res
+}
+
+type streamStatus struct {
+	streamError
+	StreamId string
+	Labels   map[string]string
+	// Status is nil until the stream reports its first status
+	Status    *lagStatus
+	State     State
+	UpdatedAt time.Time
+}
+
+type networkStatus struct {
+	Network string
+	// Status is nil if no stream of the network has reported a status yet
+	Status      *lagStatus
+	Aggregation string
+	StreamCount int
+	State       State
+	// Streams are local streams of the network, sorted by id
+	Streams []streamStatus
+}
+
+// networkStatuses aggregates statuses of streams by network, sorted by network.
+// Networks are reported once any of their streams has a status or an error.
+func (s *ConsumeStatusServer) networkStatuses() []networkStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	now := time.Now()
+
+	statusesByNetwork := make(map[string][]IndexingStatus)
+	streamsByNetwork := make(map[string][]streamStatus)
+	streamCountByNetwork := make(map[string]int)
+	staleNetworks := make(map[string]bool)
+	stateByNetwork := make(map[string]State)
+	for streamId, network := range s.networkByStreamId {
+		stream := streamStatus{
+			streamError: s.errorByStreamId[streamId],
+			StreamId:    streamId,
+			Labels:      s.labelsByStreamId[streamId],
+			State:       s.streamState(streamId),
+			UpdatedAt:   s.updatedAtByStreamId[streamId],
+		}
+		indexingStatus, hasStatus := s.statusByStreamId[streamId]
+		if !hasStatus && stream.ErrorCount == 0 {
+			continue
+		}
+		if hasStatus {
+			_, stale := s.staleStreamIds[streamId]
+			stream.Status = s.lag(network, indexingStatus, now)
+			stream.Status.Stale = stale
+			statusesByNetwork[network] = append(statusesByNetwork[network], indexingStatus)
+			streamCountByNetwork[network]++
+			staleNetworks[network] = staleNetworks[network] || stale
+		}
+		streamsByNetwork[network] = append(streamsByNetwork[network], stream)
+		stateByNetwork[network] = utils.Max(stateByNetwork[network], stream.State)
+	}
+	for _, upstream := range s.upstreams {
+		for network, status := range upstream.networks {
+			statusesByNetwork[network] = append(statusesByNetwork[network], status.IndexingStatus)
+			streamCountByNetwork[network] += utils.Max(status.StreamCount, 1)
+			staleNetworks[network] = staleNetworks[network] || !upstream.reachable
+			stateByNetwork[network] = utils.Max(stateByNetwork[network], status.State)
 		}
 	}
-	s.mu.RUnlock()
 
-	res := &pb.GetStatusResponse{Networks: make([]*pb.NetworkIndexingStatus, 0)}
-	for network, status := range networkStatuses {
-		res.Networks = append(res.Networks, &pb.NetworkIndexingStatus{
-			Network: network,
-			Status: &pb.IndexingStatus{
-				Timestamp:   timestamppb.New(status.Timestamp),
-				BlockNumber: &status.BlockNumber,
-			},
+	res := make([]networkStatus, 0, len(stateByNetwork))
+	for network, state := range stateByNetwork {
+		aggregation, ok := s.aggregationByNetwork[network]
+		if !ok {
+			aggregation = s.defaultAggregation
+		}
+		streams := streamsByNetwork[network]
+		sort.Slice(streams, func(i, j int) bool {
+			return streams[i].StreamId < streams[j].StreamId
 		})
+		status := networkStatus{
+			Network:     network,
+			Aggregation: aggregation.Name(),
+			StreamCount: streamCountByNetwork[network],
+			State:       state,
+			Streams:     streams,
+		}
+		if statuses := statusesByNetwork[network]; len(statuses) > 0 {
+			status.Status = s.lag(network, aggregation.Aggregate(statuses), now)
+			status.Status.Stale = staleNetworks[network]
+		}
+		res = append(res, status)
 	}
-	return res, nil
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Network < res[j].Network
+	})
+	return res
 }
@@ -0,0 +1,122 @@
+package consume_status
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	pb "github.com/proxima-one/indexer-utils-go/v2/pkg/consume_status/internal/proto"
+	"github.com/proxima-one/indexer-utils-go/v2/pkg/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type upstreamNetwork struct {
+	IndexingStatus
+	StreamCount int
+	State       State
+}
+
+type upstreamState struct {
+	address   string
+	networks  map[string]upstreamNetwork
+	reachable bool
+	lastSeen  time.Time
+	err       error
+}
+
+// WithUpstreams makes the server poll GetStatus of other StatusService servers every interval and merge their
+// networks into its own. Networks reported by several servers are aggregated with the configured aggregation,
+// as if every upstream network was one more stream. Data of unreachable upstreams is kept and reported as stale.
+func (s *ConsumeStatusServer) WithUpstreams(addresses []string, interval time.Duration, dialOptions ...grpc.DialOption) *ConsumeStatusServer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, address := range addresses {
+		upstream := &upstreamState{address: address, networks: make(map[string]upstreamNetwork)}
+		s.upstreams = append(s.upstreams, upstream)
+		s.backgroundTasks = append(s.backgroundTasks, func(ctx context.Context) {
+			s.pollUpstream(ctx, upstream, interval, dialOptions)
+		})
+	}
+	return s
+}
+
+func (s *ConsumeStatusServer) pollUpstream(ctx context.Context, upstream *upstreamState, interval time.Duration, dialOptions []grpc.DialOption) {
+	conn, err := grpc.DialContext(
+		ctx,
+		upstream.address,
+		append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, dialOptions...)...,
+	)
+	if err != nil {
+		log.Printf("failed to dial upstream %s: %v", upstream.address, err)
+		s.mu.Lock()
+		upstream.err = err
+		s.mu.Unlock()
+		return
+	}
+	defer conn.Close()
+	client := pb.NewStatusServiceClient(conn)
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for ctx.Err() == nil {
+		reqCtx, cancel := context.WithTimeout(ctx, interval)
+		res, err := client.GetStatus(reqCtx, &emptypb.Empty{})
+		cancel()
+
+		s.mu.Lock()
+		upstream.err = err
+		upstream.reachable = err == nil
+		if err == nil {
+			upstream.lastSeen = time.Now()
+			upstream.networks = make(map[string]upstreamNetwork, len(res.Networks))
+			for _, network := range res.Networks {
+				if network.Status == nil {
+					continue
+				}
+				if _, err := utils.StringToInt64(network.Status.GetBlockNumber()); err != nil {
+					upstream.err = fmt.Errorf("invalid block number %q of %s: %w",
+						network.Status.GetBlockNumber(), network.Network, err)
+					continue
+				}
+				upstream.networks[network.Network] = upstreamNetwork{
+					IndexingStatus: IndexingStatus{
+						Timestamp:   network.Status.Timestamp.AsTime(),
+						BlockNumber: network.Status.GetBlockNumber(),
+					},
+					StreamCount: int(network.StreamCount),
+					State:       stateFromProto(network.State),
+				}
+			}
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+		case <-t.C:
+		}
+	}
+}
+
+// upstreamsToProto must be called with s.mu held
+func (s *ConsumeStatusServer) upstreamsToProto() []*pb.UpstreamStatus {
+	res := make([]*pb.UpstreamStatus, 0, len(s.upstreams))
+	for _, upstream := range s.upstreams {
+		status := &pb.UpstreamStatus{
+			Address:   upstream.address,
+			Reachable: upstream.reachable,
+		}
+		if !upstream.lastSeen.IsZero() {
+			status.LastSeen = timestamppb.New(upstream.lastSeen)
+		}
+		if upstream.err != nil {
+			errText := upstream.err.Error()
+			status.Error = &errText
+		}
+		res = append(res, status)
+	}
+	return res
+}
@@ -0,0 +1,72 @@
+package consume_status
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	pb "github.com/proxima-one/indexer-utils-go/v2/pkg/consume_status/internal/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type fakeUpstream struct {
+	pb.UnimplementedStatusServiceServer
+	networks []*pb.NetworkIndexingStatus
+}
+
+func (f *fakeUpstream) GetStatus(context.Context, *emptypb.Empty) (*pb.GetStatusResponse, error) {
+	return &pb.GetStatusResponse{Networks: f.networks}, nil
+}
+
+func TestUpstreamWithInvalidBlockNumber(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	upstreamServer := grpc.NewServer()
+	blockNumber := func(s string) *string { return &s }
+	pb.RegisterStatusServiceServer(upstreamServer, &fakeUpstream{networks: []*pb.NetworkIndexingStatus{
+		{Network: "eth", Status: &pb.IndexingStatus{Timestamp: timestamppb.Now(), BlockNumber: blockNumber("100")}},
+		{Network: "bsc", Status: &pb.IndexingStatus{Timestamp: timestamppb.Now(), BlockNumber: blockNumber("0x10")}},
+	}})
+	go func() { _ = upstreamServer.Serve(lis) }()
+	t.Cleanup(upstreamServer.Stop)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	s := NewConsumeStatusServer().WithUpstreams([]string{lis.Addr().String()}, 10*time.Millisecond)
+	for _, task := range s.backgroundTasks {
+		go task(ctx)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.mu.RLock()
+		upstream := *s.upstreams[0]
+		s.mu.RUnlock()
+		if upstream.reachable {
+			if _, ok := upstream.networks["bsc"]; ok {
+				t.Fatal("network with an invalid block number was merged")
+			}
+			if upstream.err == nil {
+				t.Fatal("invalid block number is not reported as the upstream error")
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("upstream not polled: %v", upstream.err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	statuses := s.networkStatuses()
+	if len(statuses) != 1 || statuses[0].Network != "eth" {
+		t.Errorf("statuses = %+v, want only eth", statuses)
+	}
+	if ready, reasons := s.Readiness(); !ready {
+		t.Errorf("not ready: %v", reasons)
+	}
+}
@@ -0,0 +1,135 @@
+package consume_status
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/proxima-one/indexer-utils-go/v2/pkg/grpc_gateway"
+)
+
+func newTestHTTPServer(t *testing.T, s *ConsumeStatusServer) *httptest.Server {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	srv := httptest.NewServer(s.newHTTPHandler(ctx))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWithHTTPMiddleware(t *testing.T) {
+	var seen []string
+	s := NewConsumeStatusServer().WithHTTPMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			seen = append(seen, r.URL.Path)
+			next.ServeHTTP(w, r)
+		})
+	})
+	srv := newTestHTTPServer(t, s)
+
+	for _, path := range []string{"/api/get_status", "/api/v2/status", "/healthz", "/proto/schema.json"} {
+		res, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+	if len(seen) != 2 || seen[0] != "/api/get_status" || seen[1] != "/api/v2/status" {
+		t.Errorf("middleware saw %v, want only the REST calls", seen)
+	}
+}
+
+func TestWithAuthOverHTTP(t *testing.T) {
+	tests := []struct {
+		name     string
+		basePath string
+	}{
+		{"no base path", ""},
+		{"base path", "/status"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewConsumeStatusServer().WithAuth(TokenAuth("secret"))
+			if tt.basePath != "" {
+				s.WithGatewayOptions(grpc_gateway.WithBasePath(tt.basePath))
+			}
+			srv := newTestHTTPServer(t, s)
+
+			cases := []struct {
+				path   string
+				token  string
+				status int
+			}{
+				{tt.basePath + "/api/get_status", "", http.StatusUnauthorized},
+				{tt.basePath + "/api/v2/status", "wrong", http.StatusUnauthorized},
+				{tt.basePath + "/api/v2/status", "secret", http.StatusOK},
+				{tt.basePath + "/proto/schema.json", "", http.StatusOK},
+				{"/healthz", "", http.StatusOK},
+			}
+			for _, c := range cases {
+				req, err := http.NewRequest(http.MethodGet, srv.URL+c.path, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if c.token != "" {
+					req.Header.Set("Authorization", "Bearer "+c.token)
+				}
+				res, err := http.DefaultClient.Do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				res.Body.Close()
+				if res.StatusCode != c.status {
+					t.Errorf("GET %s with token %q: status %d, want %d", c.path, c.token, res.StatusCode, c.status)
+				}
+			}
+		})
+	}
+}
+
+func TestAuthErrorsUseGatewayErrorHandler(t *testing.T) {
+	s := NewConsumeStatusServer().
+		WithAuth(TokenAuth("secret")).
+		WithCORS(grpc_gateway.CORSConfig{AllowedOrigins: []string{"https://status.example.com"}}).
+		WithGatewayOptions(grpc_gateway.WithErrorHandler(grpc_gateway.ProblemJSONErrorHandler))
+	srv := newTestHTTPServer(t, s)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/api/v2/status", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Origin", "https://status.example.com")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", res.StatusCode)
+	}
+	if contentType := res.Header.Get("Content-Type"); contentType != "application/problem+json" {
+		t.Errorf("content type = %q, want application/problem+json", contentType)
+	}
+	if origin := res.Header.Get("Access-Control-Allow-Origin"); origin != "https://status.example.com" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want the request origin", origin)
+	}
+
+	req, err = http.NewRequest(http.MethodOptions, srv.URL+"/api/v2/status", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Origin", "https://evil.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	res, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("preflight status = %d, want 403", res.StatusCode)
+	}
+	if contentType := res.Header.Get("Content-Type"); contentType != "application/problem+json" {
+		t.Errorf("preflight content type = %q, want application/problem+json", contentType)
+	}
+}
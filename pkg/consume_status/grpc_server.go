@@ -0,0 +1,85 @@
+package consume_status
+
+import (
+	"google.golang.org/grpc"
+	channelz "google.golang.org/grpc/channelz/service"
+	"google.golang.org/grpc/reflection"
+)
+
+type grpcServerOptions struct {
+	reflection         bool
+	channelz           bool
+	services           []func(*grpc.Server)
+	unaryInterceptors  []grpc.UnaryServerInterceptor
+	streamInterceptors []grpc.StreamServerInterceptor
+}
+
+// EnableReflection registers gRPC server reflection, so tools like grpcurl work without the proto files.
+func (s *ConsumeStatusServer) EnableReflection() *ConsumeStatusServer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.grpcOptions.reflection = true
+	return s
+}
+
+// EnableChannelz registers the channelz service exposing gRPC connection internals.
+func (s *ConsumeStatusServer) EnableChannelz() *ConsumeStatusServer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.grpcOptions.channelz = true
+	return s
+}
+
+// RegisterGRPCService calls register with the gRPC server when it's created, so the caller can serve
+// its own services on the status server port.
+func (s *ConsumeStatusServer) RegisterGRPCService(register func(*grpc.Server)) *ConsumeStatusServer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.grpcOptions.services = append(s.grpcOptions.services, register)
+	return s
+}
+
+// WithUnaryInterceptors adds interceptors run after authentication, in the given order.
+// They don't run for REST calls, which the gateway serves in-process; see WithHTTPMiddleware.
+func (s *ConsumeStatusServer) WithUnaryInterceptors(interceptors ...grpc.UnaryServerInterceptor) *ConsumeStatusServer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.grpcOptions.unaryInterceptors = append(s.grpcOptions.unaryInterceptors, interceptors...)
+	return s
+}
+
+// WithStreamInterceptors adds interceptors run after authentication, in the given order.
+// They don't run for REST calls, which the gateway serves in-process; see WithHTTPMiddleware.
+func (s *ConsumeStatusServer) WithStreamInterceptors(interceptors ...grpc.StreamServerInterceptor) *ConsumeStatusServer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.grpcOptions.streamInterceptors = append(s.grpcOptions.streamInterceptors, interceptors...)
+	return s
+}
+
+func (s *ConsumeStatusServer) grpcServerOptions() []grpc.ServerOption {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	unary := append([]grpc.UnaryServerInterceptor{s.unaryAuthInterceptor}, s.grpcOptions.unaryInterceptors...)
+	stream := append([]grpc.StreamServerInterceptor{s.streamAuthInterceptor}, s.grpcOptions.streamInterceptors...)
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	}
+}
+
+// registerExtraServices registers optional and caller-supplied services
+func (s *ConsumeStatusServer) registerExtraServices(grpcServer *grpc.Server) {
+	s.mu.RLock()
+	options := s.grpcOptions
+	s.mu.RUnlock()
+	if options.reflection {
+		reflection.Register(grpcServer)
+	}
+	if options.channelz {
+		channelz.RegisterChannelzServiceToServer(grpcServer)
+	}
+	for _, register := range options.services {
+		register(grpcServer)
+	}
+}
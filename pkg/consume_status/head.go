@@ -0,0 +1,127 @@
+package consume_status
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/proxima-one/indexer-utils-go/v2/pkg/utils"
+)
+
+// HeadProvider is polled for the chain head of every registered network.
+type HeadProvider interface {
+	GetHead(ctx context.Context, network string) (IndexingStatus, error)
+}
+
+// SyncThreshold defines when a network is considered synced. Zero fields are not checked.
+// Blocks is checked only when the chain head of the network is known.
+type SyncThreshold struct {
+	Blocks int64
+	Lag    time.Duration
+}
+
+// DefaultSyncThreshold is used for networks without a specific threshold.
+var DefaultSyncThreshold = SyncThreshold{Lag: time.Minute}
+
+func (t SyncThreshold) isSynced(blocksBehind *int64, timeLag time.Duration) bool {
+	if t.Blocks > 0 && blocksBehind != nil && *blocksBehind > t.Blocks {
+		return false
+	}
+	if t.Lag > 0 && timeLag > t.Lag {
+		return false
+	}
+	return true
+}
+
+// UpdateNetworkHead sets the chain head of the network. Use it to push heads, or WithHeadProvider to pull them.
+// It fails if blockNumber is not a decimal integer.
+func (s *ConsumeStatusServer) UpdateNetworkHead(network string, timestamp time.Time, blockNumber string) error {
+	if _, err := utils.StringToInt64(blockNumber); err != nil {
+		return fmt.Errorf("invalid head block number %q of %s: %w", blockNumber, network, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.headByNetwork[network] = IndexingStatus{
+		Timestamp:   timestamp,
+		BlockNumber: blockNumber,
+	}
+	return nil
+}
+
+// WithHeadProvider polls the provider for heads of registered networks every interval while the server is running.
+func (s *ConsumeStatusServer) WithHeadProvider(provider HeadProvider, interval time.Duration) *ConsumeStatusServer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.backgroundTasks = append(s.backgroundTasks, func(ctx context.Context) {
+		s.pollHeads(ctx, provider, interval)
+	})
+	return s
+}
+
+// WithSyncThreshold sets the threshold for networks without a specific one.
+func (s *ConsumeStatusServer) WithSyncThreshold(threshold SyncThreshold) *ConsumeStatusServer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.defaultSyncThreshold = threshold
+	return s
+}
+
+// WithNetworkSyncThreshold sets the threshold used to compute is_synced for the network.
+func (s *ConsumeStatusServer) WithNetworkSyncThreshold(network string, threshold SyncThreshold) *ConsumeStatusServer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.syncThresholdByNetwork[network] = threshold
+	return s
+}
+
+func (s *ConsumeStatusServer) pollHeads(ctx context.Context, provider HeadProvider, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for ctx.Err() == nil {
+		s.mu.RLock()
+		networks := make(map[string]struct{})
+		for _, network := range s.networkByStreamId {
+			networks[network] = struct{}{}
+		}
+		s.mu.RUnlock()
+
+		for network := range networks {
+			head, err := provider.GetHead(ctx, network)
+			if err != nil {
+				log.Printf("failed to get head of %s: %v", network, err)
+				continue
+			}
+			if err := s.UpdateNetworkHead(network, head.Timestamp, head.BlockNumber); err != nil {
+				log.Printf("skipping head of %s: %v", network, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-t.C:
+		}
+	}
+}
+
+// lag compares the indexed status to the chain head. Must be called with s.mu held.
+func (s *ConsumeStatusServer) lag(network string, status IndexingStatus, now time.Time) *lagStatus {
+	res := &lagStatus{
+		IndexingStatus: status,
+		TimeLag:        now.Sub(status.Timestamp),
+	}
+	if head, ok := s.headByNetwork[network]; ok {
+		res.Head = &head
+		blocksBehind := utils.Max(utils.MustConvStrToInt64(head.BlockNumber)-utils.MustConvStrToInt64(status.BlockNumber), 0)
+		res.BlocksBehind = &blocksBehind
+		res.TimeLag = head.Timestamp.Sub(status.Timestamp)
+	}
+	res.TimeLag = utils.Max(res.TimeLag, 0)
+
+	threshold, ok := s.syncThresholdByNetwork[network]
+	if !ok {
+		threshold = s.defaultSyncThreshold
+	}
+	res.IsSynced = threshold.isSynced(res.BlocksBehind, res.TimeLag)
+	return res
+}
@@ -0,0 +1,26 @@
+package consume_status
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUpdateNetworkHeadRejectsInvalidBlockNumber(t *testing.T) {
+	s := NewConsumeStatusServer()
+	s.RegisterStream("stream", "eth")
+	s.UpdateStreamStatus("stream", time.Now(), "100")
+
+	if err := s.UpdateNetworkHead("eth", time.Now(), "0x10"); err == nil {
+		t.Fatal("expected an error for a non-decimal block number")
+	}
+	if _, ok := s.headByNetwork["eth"]; ok {
+		t.Fatal("invalid head was stored")
+	}
+	if err := s.UpdateNetworkHead("eth", time.Now(), "150"); err != nil {
+		t.Fatal(err)
+	}
+	statuses := s.networkStatuses()
+	if len(statuses) != 1 || statuses[0].Status == nil || statuses[0].Status.BlocksBehind == nil || *statuses[0].Status.BlocksBehind != 50 {
+		t.Errorf("statuses = %+v, want 50 blocks behind", statuses)
+	}
+}
@@ -0,0 +1,102 @@
+package consume_status
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	pb "github.com/proxima-one/indexer-utils-go/v2/pkg/consume_status/internal/proto"
+	statusv2 "github.com/proxima-one/indexer-utils-go/v2/pkg/consume_status/proto/v2"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const healthCheckInterval = time.Second
+
+// WithStallTimeout makes the server not ready when a registered stream has not been updated for longer than timeout.
+// Disabled by default.
+func (s *ConsumeStatusServer) WithStallTimeout(timeout time.Duration) *ConsumeStatusServer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stallTimeout = timeout
+	return s
+}
+
+// Readiness reports whether every network is within its sync threshold and no stream is stalled.
+// Reachability of upstreams is reported in GetStatus instead, so one unreachable upstream doesn't fail the federation.
+// If not ready, reasons describe every failed check.
+func (s *ConsumeStatusServer) Readiness() (ready bool, reasons []string) {
+	for _, status := range s.networkStatuses() {
+		if status.Status != nil && !status.Status.IsSynced {
+			reasons = append(reasons, fmt.Sprintf("network %s is not synced: lag %s", status.Network,
+				status.Status.TimeLag.Truncate(time.Second)))
+		}
+	}
+
+	s.mu.RLock()
+	now := time.Now()
+	for streamId := range s.networkByStreamId {
+		updatedAt, ok := s.updatedAtByStreamId[streamId]
+		if !ok {
+			reasons = append(reasons, fmt.Sprintf("stream %s has no status yet", streamId))
+		} else if s.stallTimeout > 0 && now.Sub(updatedAt) > s.stallTimeout {
+			reasons = append(reasons, fmt.Sprintf("stream %s is stalled: last updated %s ago", streamId,
+				now.Sub(updatedAt).Truncate(time.Second)))
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Strings(reasons)
+	return len(reasons) == 0, reasons
+}
+
+// newHealthServer returns a grpc.health.v1 server reporting readiness for the whole server and both StatusService versions.
+// It is updated every healthCheckInterval until ctx is done.
+func (s *ConsumeStatusServer) newHealthServer(ctx context.Context) *health.Server {
+	healthServer := health.NewServer()
+	update := func() {
+		status := grpc_health_v1.HealthCheckResponse_NOT_SERVING
+		if ready, _ := s.Readiness(); ready {
+			status = grpc_health_v1.HealthCheckResponse_SERVING
+		}
+		healthServer.SetServingStatus("", status)
+		healthServer.SetServingStatus(pb.StatusService_ServiceDesc.ServiceName, status)
+		healthServer.SetServingStatus(statusv2.StatusServiceV2_ServiceDesc.ServiceName, status)
+	}
+	update()
+
+	go func() {
+		t := time.NewTicker(healthCheckInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				healthServer.Shutdown()
+				return
+			case <-t.C:
+				update()
+			}
+		}
+	}()
+	return healthServer
+}
+
+func (s *ConsumeStatusServer) serveHealthz(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte("ok"))
+}
+
+func (s *ConsumeStatusServer) serveReadyz(w http.ResponseWriter, _ *http.Request) {
+	ready, reasons := s.Readiness()
+	w.Header().Set("Content-Type", "application/json")
+	if !ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(struct {
+		Ready   bool     `json:"ready"`
+		Reasons []string `json:"reasons,omitempty"`
+	}{ready, reasons})
+}
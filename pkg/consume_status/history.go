@@ -0,0 +1,232 @@
+package consume_status
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	pb "github.com/proxima-one/indexer-utils-go/v2/pkg/consume_status/internal/proto"
+	"github.com/proxima-one/indexer-utils-go/v2/pkg/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultHistoryResolution = time.Minute
+	defaultHistoryRetention  = 24 * time.Hour
+)
+
+type historyPoint struct {
+	SampledAt time.Time
+	IndexingStatus
+}
+
+// historyRing keeps the last len(points) points, overwriting the oldest ones
+type historyRing struct {
+	points []historyPoint
+	next   int
+	full   bool
+}
+
+func newHistoryRing(capacity int) *historyRing {
+	return &historyRing{points: make([]historyPoint, capacity)}
+}
+
+func (r *historyRing) add(point historyPoint) {
+	r.points[r.next] = point
+	r.next = (r.next + 1) % len(r.points)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// latest returns the time of the newest point
+func (r *historyRing) latest() time.Time {
+	return r.points[(r.next+len(r.points)-1)%len(r.points)].SampledAt
+}
+
+// between returns points sampled in [from, to], oldest first
+func (r *historyRing) between(from, to time.Time) []historyPoint {
+	var ordered []historyPoint
+	if r.full {
+		ordered = append(ordered, r.points[r.next:]...)
+	}
+	ordered = append(ordered, r.points[:r.next]...)
+
+	res := make([]historyPoint, 0, len(ordered))
+	for _, point := range ordered {
+		if !point.SampledAt.Before(from) && !point.SampledAt.After(to) {
+			res = append(res, point)
+		}
+	}
+	return res
+}
+
+type statusHistory struct {
+	mu          sync.RWMutex
+	capacity    int
+	retention   time.Duration
+	byNetwork   map[string]*historyRing
+	byStreamId  map[string]*historyRing
+	streamToNet map[string]string
+}
+
+// WithHistory samples network and stream statuses every resolution while the server is running and keeps them
+// for the retention period. The history is served by GetStatusHistory. Resolution defaults to 1m and retention
+// to 24h if not positive. The history of networks and streams that are gone is dropped after the retention period.
+func (s *ConsumeStatusServer) WithHistory(resolution, retention time.Duration) *ConsumeStatusServer {
+	if resolution <= 0 {
+		resolution = defaultHistoryResolution
+	}
+	if retention <= 0 {
+		retention = defaultHistoryRetention
+	}
+	history := &statusHistory{
+		capacity:    utils.Max(int(retention/resolution), 1),
+		retention:   retention,
+		byNetwork:   make(map[string]*historyRing),
+		byStreamId:  make(map[string]*historyRing),
+		streamToNet: make(map[string]string),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history = history
+	s.backgroundTasks = append(s.backgroundTasks, func(ctx context.Context) {
+		t := time.NewTicker(resolution)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-t.C:
+				s.sampleHistory(history, now)
+			}
+		}
+	})
+	return s
+}
+
+func (s *ConsumeStatusServer) sampleHistory(history *statusHistory, now time.Time) {
+	networkStatuses := s.networkStatuses()
+
+	s.mu.RLock()
+	streamStatuses := make(map[string]IndexingStatus, len(s.statusByStreamId))
+	streamNetworks := make(map[string]string, len(s.statusByStreamId))
+	for streamId, streamStatus := range s.statusByStreamId {
+		streamStatuses[streamId] = streamStatus
+		streamNetworks[streamId] = s.networkByStreamId[streamId]
+	}
+	s.mu.RUnlock()
+
+	history.mu.Lock()
+	defer history.mu.Unlock()
+	for _, networkStatus := range networkStatuses {
+		if networkStatus.Status == nil {
+			continue
+		}
+		ring, ok := history.byNetwork[networkStatus.Network]
+		if !ok {
+			ring = newHistoryRing(history.capacity)
+			history.byNetwork[networkStatus.Network] = ring
+		}
+		ring.add(historyPoint{SampledAt: now, IndexingStatus: networkStatus.Status.IndexingStatus})
+	}
+	for streamId, streamStatus := range streamStatuses {
+		ring, ok := history.byStreamId[streamId]
+		if !ok {
+			ring = newHistoryRing(history.capacity)
+			history.byStreamId[streamId] = ring
+		}
+		ring.add(historyPoint{SampledAt: now, IndexingStatus: streamStatus})
+		history.streamToNet[streamId] = streamNetworks[streamId]
+	}
+	history.prune(now)
+}
+
+// prune drops the rings that were not sampled during the retention period. It must be called with history.mu held.
+func (history *statusHistory) prune(now time.Time) {
+	expired := now.Add(-history.retention)
+	for network, ring := range history.byNetwork {
+		if ring.latest().Before(expired) {
+			delete(history.byNetwork, network)
+		}
+	}
+	for streamId, ring := range history.byStreamId {
+		if ring.latest().Before(expired) {
+			delete(history.byStreamId, streamId)
+			delete(history.streamToNet, streamId)
+		}
+	}
+}
+
+func (s *ConsumeStatusServer) GetStatusHistory(_ context.Context, req *pb.GetStatusHistoryRequest) (*pb.GetStatusHistoryResponse, error) {
+	s.mu.RLock()
+	history := s.history
+	s.mu.RUnlock()
+	if history == nil {
+		return nil, status.Error(codes.FailedPrecondition, "status history is disabled")
+	}
+
+	from, to := time.Time{}, time.Now()
+	if req.From != nil {
+		from = req.From.AsTime()
+	}
+	if req.To != nil {
+		to = req.To.AsTime()
+	}
+	if from.After(to) {
+		return nil, status.Error(codes.InvalidArgument, "from must not be after to")
+	}
+
+	history.mu.RLock()
+	defer history.mu.RUnlock()
+
+	res := &pb.GetStatusHistoryResponse{
+		Networks: make([]*pb.NetworkStatusHistory, 0),
+		Streams:  make([]*pb.StreamStatusHistory, 0),
+	}
+	for network, ring := range history.byNetwork {
+		if req.Network != nil && *req.Network != network {
+			continue
+		}
+		res.Networks = append(res.Networks, &pb.NetworkStatusHistory{
+			Network: network,
+			Points:  historyPointsToProto(ring.between(from, to)),
+		})
+	}
+	if req.IncludeStreams {
+		for streamId, ring := range history.byStreamId {
+			network := history.streamToNet[streamId]
+			if req.Network != nil && *req.Network != network {
+				continue
+			}
+			res.Streams = append(res.Streams, &pb.StreamStatusHistory{
+				StreamId: streamId,
+				Network:  network,
+				Points:   historyPointsToProto(ring.between(from, to)),
+			})
+		}
+	}
+	sort.Slice(res.Networks, func(i, j int) bool {
+		return res.Networks[i].Network < res.Networks[j].Network
+	})
+	sort.Slice(res.Streams, func(i, j int) bool {
+		return res.Streams[i].StreamId < res.Streams[j].StreamId
+	})
+	return res, nil
+}
+
+func historyPointsToProto(points []historyPoint) []*pb.StatusHistoryPoint {
+	res := make([]*pb.StatusHistoryPoint, len(points))
+	for i, point := range points {
+		res[i] = &pb.StatusHistoryPoint{
+			SampledAt:   timestamppb.New(point.SampledAt),
+			Timestamp:   timestamppb.New(point.Timestamp),
+			BlockNumber: point.BlockNumber,
+		}
+	}
+	return res
+}
@@ -0,0 +1,42 @@
+package consume_status
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithHistoryDefaults(t *testing.T) {
+	s := NewConsumeStatusServer().WithHistory(0, -time.Second)
+	if s.history.retention != defaultHistoryRetention {
+		t.Errorf("retention = %v, want %v", s.history.retention, defaultHistoryRetention)
+	}
+	if want := int(defaultHistoryRetention / defaultHistoryResolution); s.history.capacity != want {
+		t.Errorf("capacity = %d, want %d", s.history.capacity, want)
+	}
+}
+
+func TestHistoryPrunesGoneNetworks(t *testing.T) {
+	s := NewConsumeStatusServer().WithHistory(time.Minute, time.Hour)
+	s.RegisterStream("stream-1", "eth")
+	s.UpdateStreamStatus("stream-1", time.Now(), "100")
+
+	start := time.Now()
+	s.sampleHistory(s.history, start)
+	s.RegisterStream("stream-1", "bsc")
+
+	s.sampleHistory(s.history, start.Add(30*time.Minute))
+	if _, ok := s.history.byNetwork["eth"]; !ok {
+		t.Fatal("eth history dropped before the retention period")
+	}
+
+	s.sampleHistory(s.history, start.Add(2*time.Hour))
+	if _, ok := s.history.byNetwork["eth"]; ok {
+		t.Error("eth history kept after the retention period")
+	}
+	if _, ok := s.history.byNetwork["bsc"]; !ok {
+		t.Error("bsc history dropped")
+	}
+	if network := s.history.streamToNet["stream-1"]; network != "bsc" {
+		t.Errorf("stream-1 network = %q, want bsc", network)
+	}
+}
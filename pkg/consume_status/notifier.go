@@ -0,0 +1,296 @@
+package consume_status
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultNotifyInterval     = 10 * time.Second
+	defaultNotifyRetries      = 3
+	defaultNotifyRetryBackoff = time.Second
+	notificationQueueSize     = 100
+)
+
+type NotificationEvent string
+
+const (
+	NotificationFiring   NotificationEvent = "firing"
+	NotificationResolved NotificationEvent = "resolved"
+)
+
+// NotificationRule fires when a network falls behind its head by more than MaxLag or MaxBlocksBehind.
+// Zero thresholds are disabled. The rule resolves once the network is back within ResolveLag and
+// ResolveBlocksBehind, which default to the firing thresholds.
+type NotificationRule struct {
+	Name string
+	// Network the rule applies to, all networks if empty
+	Network         string
+	MaxLag          time.Duration
+	MaxBlocksBehind int64
+
+	ResolveLag          time.Duration
+	ResolveBlocksBehind int64
+	// For is how long a condition must hold before the rule fires or resolves
+	For time.Duration
+}
+
+// Notification is sent when a rule starts firing or resolves
+type Notification struct {
+	Event           NotificationEvent `json:"event"`
+	Rule            string            `json:"rule"`
+	Network         string            `json:"network"`
+	BlockNumber     string            `json:"block_number"`
+	HeadBlockNumber string            `json:"head_block_number,omitempty"`
+	BlocksBehind    *int64            `json:"blocks_behind,omitempty"`
+	TimeLagSeconds  float64           `json:"time_lag_seconds"`
+	Timestamp       time.Time         `json:"timestamp"`
+}
+
+type NotificationSender interface {
+	Send(ctx context.Context, notification Notification) error
+}
+
+type webhookSender struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookSender returns a sender POSTing notifications as JSON to the url. http.DefaultClient is used if client is nil.
+func NewWebhookSender(url string, client *http.Client) NotificationSender {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &webhookSender{url: url, client: client}
+}
+
+func (w *webhookSender) Send(ctx context.Context, notification Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s responded with %s", w.url, res.Status)
+	}
+	return nil
+}
+
+type NotifierConfig struct {
+	Rules   []NotificationRule
+	Senders []NotificationSender
+	// Interval between rule evaluations, 10s by default
+	Interval time.Duration
+	// Retries of a failed send, 3 by default. Negative disables retries.
+	Retries int
+	// RetryBackoff is the delay before the first retry, doubled on each next one. 1s by default.
+	RetryBackoff time.Duration
+}
+
+// WithNotifier evaluates the rules against network statuses and sends a notification to every sender
+// when a rule starts firing for a network and when it resolves.
+func (s *ConsumeStatusServer) WithNotifier(config NotifierConfig) *ConsumeStatusServer {
+	if config.Interval <= 0 {
+		config.Interval = defaultNotifyInterval
+	}
+	if config.Retries == 0 {
+		config.Retries = defaultNotifyRetries
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = defaultNotifyRetryBackoff
+	}
+	n := &notifier{
+		config:       config,
+		stateByAlert: make(map[alertKey]*alertState),
+		queue:        make(chan Notification, notificationQueueSize),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.backgroundTasks = append(s.backgroundTasks, func(ctx context.Context) {
+		go n.deliver(ctx)
+		t := time.NewTicker(config.Interval)
+		defer t.Stop()
+		for {
+			n.evaluate(s.networkStatuses(), time.Now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+	})
+	return s
+}
+
+type alertKey struct {
+	rule    int
+	network string
+}
+
+type alertState struct {
+	firing bool
+	// pendingSince is when the condition to change firing started to hold
+	pendingSince time.Time
+	// status is the last evaluated status, reported when the alert is resolved because the network is gone
+	status *lagStatus
+}
+
+type notifier struct {
+	config       NotifierConfig
+	stateByAlert map[alertKey]*alertState
+	queue        chan Notification
+}
+
+func (n *notifier) evaluate(statuses []networkStatus, now time.Time) {
+	evaluated := make(map[alertKey]struct{}, len(n.stateByAlert))
+	for i, rule := range n.config.Rules {
+		for _, status := range statuses {
+			if status.Status == nil || rule.Network != "" && rule.Network != status.Network {
+				continue
+			}
+			key := alertKey{rule: i, network: status.Network}
+			state, ok := n.stateByAlert[key]
+			if !ok {
+				state = &alertState{}
+				n.stateByAlert[key] = state
+			}
+			state.status = status.Status
+			evaluated[key] = struct{}{}
+
+			var changing bool
+			if state.firing {
+				changing = rule.resolved(status.Status)
+			} else {
+				changing = rule.breached(status.Status)
+			}
+			if !changing {
+				state.pendingSince = time.Time{}
+				continue
+			}
+			if state.pendingSince.IsZero() {
+				state.pendingSince = now
+			}
+			if now.Sub(state.pendingSince) < rule.For {
+				continue
+			}
+
+			event := NotificationFiring
+			if state.firing {
+				event = NotificationResolved
+			}
+			// the state only changes once the notification is queued, so a dropped one is retried next time
+			if n.enqueue(rule.notification(event, status.Network, status.Status, now)) {
+				state.firing = !state.firing
+				state.pendingSince = time.Time{}
+			}
+		}
+	}
+
+	// the network is gone or lost its status: resolve its firing alerts and forget them
+	for key, state := range n.stateByAlert {
+		if _, ok := evaluated[key]; ok {
+			continue
+		}
+		rule := &n.config.Rules[key.rule]
+		if state.firing && !n.enqueue(rule.notification(NotificationResolved, key.network, state.status, now)) {
+			continue
+		}
+		delete(n.stateByAlert, key)
+	}
+}
+
+func (n *notifier) enqueue(notification Notification) bool {
+	select {
+	case n.queue <- notification:
+		return true
+	default:
+		log.Printf("notification queue is full, postponing %s notification of rule %s for %s",
+			notification.Event, notification.Rule, notification.Network)
+		return false
+	}
+}
+
+// deliver sends queued notifications in order, so a resolution never overtakes its firing
+func (n *notifier) deliver(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case notification := <-n.queue:
+			for _, sender := range n.config.Senders {
+				if err := n.send(ctx, sender, notification); err != nil {
+					log.Printf("failed to send %s notification of rule %s for %s: %v",
+						notification.Event, notification.Rule, notification.Network, err)
+				}
+			}
+		}
+	}
+}
+
+func (n *notifier) send(ctx context.Context, sender NotificationSender, notification Notification) error {
+	backoff := n.config.RetryBackoff
+	err := sender.Send(ctx, notification)
+	for attempt := 0; err != nil && attempt < n.config.Retries; attempt++ {
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		err = sender.Send(ctx, notification)
+	}
+	return err
+}
+
+func (r *NotificationRule) breached(status *lagStatus) bool {
+	return exceeds(status, r.MaxLag, r.MaxBlocksBehind)
+}
+
+func (r *NotificationRule) resolved(status *lagStatus) bool {
+	lag, blocks := r.ResolveLag, r.ResolveBlocksBehind
+	if lag == 0 {
+		lag = r.MaxLag
+	}
+	if blocks == 0 {
+		blocks = r.MaxBlocksBehind
+	}
+	return !exceeds(status, lag, blocks)
+}
+
+func (r *NotificationRule) notification(event NotificationEvent, network string, status *lagStatus, now time.Time) Notification {
+	res := Notification{
+		Event:          event,
+		Rule:           r.Name,
+		Network:        network,
+		BlockNumber:    status.BlockNumber,
+		BlocksBehind:   status.BlocksBehind,
+		TimeLagSeconds: status.TimeLag.Seconds(),
+		Timestamp:      now,
+	}
+	if status.Head != nil {
+		res.HeadBlockNumber = status.Head.BlockNumber
+	}
+	return res
+}
+
+func exceeds(status *lagStatus, maxLag time.Duration, maxBlocks int64) bool {
+	if maxLag > 0 && status.TimeLag > maxLag {
+		return true
+	}
+	return maxBlocks > 0 && status.BlocksBehind != nil && *status.BlocksBehind > maxBlocks
+}
@@ -0,0 +1,184 @@
+package consume_status
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhookSender(t *testing.T) {
+	var received Notification
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		if contentType := r.Header.Get("Content-Type"); contentType != "application/json" {
+			t.Errorf("content type = %q, want application/json", contentType)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Error(err)
+		}
+		if received.Rule == "rejected" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	sender := NewWebhookSender(srv.URL, nil)
+	notification := Notification{Event: NotificationFiring, Rule: "lag", Network: "eth", BlockNumber: "100"}
+	if err := sender.Send(context.Background(), notification); err != nil {
+		t.Fatal(err)
+	}
+	if received.Event != NotificationFiring || received.Rule != "lag" || received.Network != "eth" || received.BlockNumber != "100" {
+		t.Errorf("received %+v", received)
+	}
+
+	notification.Rule = "rejected"
+	if err := sender.Send(context.Background(), notification); err == nil {
+		t.Error("expected an error for a 503 response")
+	}
+}
+
+func lagged(network string, lag time.Duration) []networkStatus {
+	return []networkStatus{{Network: network, Status: &lagStatus{TimeLag: lag}}}
+}
+
+func receivedEvents(n *notifier) []NotificationEvent {
+	var res []NotificationEvent
+	for {
+		select {
+		case notification := <-n.queue:
+			res = append(res, notification.Event)
+		default:
+			return res
+		}
+	}
+}
+
+func TestNotifierHysteresis(t *testing.T) {
+	n := &notifier{
+		config: NotifierConfig{Rules: []NotificationRule{{
+			Name:       "lag",
+			MaxLag:     10 * time.Minute,
+			ResolveLag: 5 * time.Minute,
+			For:        time.Minute,
+		}}},
+		stateByAlert: make(map[alertKey]*alertState),
+		queue:        make(chan Notification, notificationQueueSize),
+	}
+	start := time.Now()
+	steps := []struct {
+		after time.Duration
+		lag   time.Duration
+		want  []NotificationEvent
+	}{
+		{0, 15 * time.Minute, nil},
+		// the breach didn't hold for a minute
+		{30 * time.Second, time.Minute, nil},
+		{time.Minute, 15 * time.Minute, nil},
+		{2 * time.Minute, 15 * time.Minute, []NotificationEvent{NotificationFiring}},
+		{3 * time.Minute, 15 * time.Minute, nil},
+		// below MaxLag, but above ResolveLag
+		{4 * time.Minute, 8 * time.Minute, nil},
+		{6 * time.Minute, 8 * time.Minute, nil},
+		{7 * time.Minute, 2 * time.Minute, nil},
+		{8 * time.Minute, 2 * time.Minute, []NotificationEvent{NotificationResolved}},
+		{9 * time.Minute, 2 * time.Minute, nil},
+	}
+	for _, step := range steps {
+		n.evaluate(lagged("eth", step.lag), start.Add(step.after))
+		got := receivedEvents(n)
+		if len(got) != len(step.want) || len(got) > 0 && got[0] != step.want[0] {
+			t.Errorf("at %v with lag %v: got %v, want %v", step.after, step.lag, got, step.want)
+		}
+	}
+}
+
+func TestNotifierRetriesQueueingDroppedNotifications(t *testing.T) {
+	n := &notifier{
+		config:       NotifierConfig{Rules: []NotificationRule{{Name: "lag", MaxLag: time.Minute}}},
+		stateByAlert: make(map[alertKey]*alertState),
+		queue:        make(chan Notification, 1),
+	}
+	n.queue <- Notification{}
+
+	now := time.Now()
+	n.evaluate(lagged("eth", time.Hour), now)
+	if n.stateByAlert[alertKey{network: "eth"}].firing {
+		t.Fatal("rule fires although its notification was dropped")
+	}
+
+	<-n.queue
+	n.evaluate(lagged("eth", time.Hour), now.Add(time.Second))
+	if got := receivedEvents(n); len(got) != 1 || got[0] != NotificationFiring {
+		t.Errorf("got %v, want the firing notification", got)
+	}
+	if !n.stateByAlert[alertKey{network: "eth"}].firing {
+		t.Error("rule doesn't fire after its notification was queued")
+	}
+}
+
+type flakySender struct {
+	failures int
+	calls    int
+}
+
+func (s *flakySender) Send(context.Context, Notification) error {
+	s.calls++
+	if s.calls <= s.failures {
+		return errors.New("unavailable")
+	}
+	return nil
+}
+
+func TestNotifierSendRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		retries   int
+		failures  int
+		wantCalls int
+		wantErr   bool
+	}{
+		{"succeeds after retries", 3, 2, 3, false},
+		{"gives up after retries", 2, 5, 3, true},
+		{"retries disabled", -1, 1, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := &notifier{config: NotifierConfig{Retries: tt.retries, RetryBackoff: time.Millisecond}}
+			sender := &flakySender{failures: tt.failures}
+			err := n.send(context.Background(), sender, Notification{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, want error: %v", err, tt.wantErr)
+			}
+			if sender.calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", sender.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestNotifierForgetsGoneNetworks(t *testing.T) {
+	n := &notifier{
+		config:       NotifierConfig{Rules: []NotificationRule{{Name: "lag", MaxLag: time.Minute}}},
+		stateByAlert: make(map[alertKey]*alertState),
+		queue:        make(chan Notification, notificationQueueSize),
+	}
+	now := time.Now()
+	n.evaluate(append(lagged("eth", time.Hour), lagged("bsc", time.Second)...), now)
+	if got := receivedEvents(n); len(got) != 1 || got[0] != NotificationFiring {
+		t.Fatalf("got %v, want the firing notification of eth", got)
+	}
+
+	n.evaluate([]networkStatus{{Network: "eth"}}, now.Add(time.Second))
+	if got := receivedEvents(n); len(got) != 1 || got[0] != NotificationResolved {
+		t.Errorf("got %v, want eth resolved once it lost its status", got)
+	}
+	if len(n.stateByAlert) != 0 {
+		t.Errorf("states of gone networks are kept: %v", n.stateByAlert)
+	}
+}
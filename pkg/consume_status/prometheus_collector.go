@@ -0,0 +1,67 @@
+package consume_status
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/proxima-one/indexer-utils-go/v2/pkg/utils"
+)
+
+var (
+	networkBlockHeightDesc = prometheus.NewDesc(
+		"index_network_block_height",
+		"Indexed block number of the network, aggregated over its streams",
+		[]string{"network"}, nil,
+	)
+	networkTimestampDesc = prometheus.NewDesc(
+		"index_network_timestamp_seconds",
+		"Timestamp of the indexed block of the network, aggregated over its streams",
+		[]string{"network"}, nil,
+	)
+	streamBlockHeightDesc = prometheus.NewDesc(
+		"index_stream_block_height",
+		"Indexed block number of the stream",
+		[]string{"network", "stream"}, nil,
+	)
+	streamTimestampDesc = prometheus.NewDesc(
+		"index_stream_timestamp_seconds",
+		"Timestamp of the indexed block of the stream",
+		[]string{"network", "stream"}, nil,
+	)
+)
+
+// statusCollector exports statuses of ConsumeStatusServer, reading them at scrape time
+type statusCollector struct {
+	s *ConsumeStatusServer
+}
+
+// RegisterPrometheusCollector registers a collector exporting block height and timestamp gauges
+// of every network and stream.
+func (s *ConsumeStatusServer) RegisterPrometheusCollector(registerer prometheus.Registerer) error {
+	return registerer.Register(&statusCollector{s: s})
+}
+
+func (c *statusCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- networkBlockHeightDesc
+	ch <- networkTimestampDesc
+	ch <- streamBlockHeightDesc
+	ch <- streamTimestampDesc
+}
+
+func (c *statusCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, network := range c.s.networkStatuses() {
+		if network.Status != nil {
+			ch <- prometheus.MustNewConstMetric(networkBlockHeightDesc, prometheus.GaugeValue,
+				float64(utils.MustConvStrToInt64(network.Status.BlockNumber)), network.Network)
+			ch <- prometheus.MustNewConstMetric(networkTimestampDesc, prometheus.GaugeValue,
+				float64(network.Status.Timestamp.UnixMilli())/1000, network.Network)
+		}
+		for _, stream := range network.Streams {
+			if stream.Status == nil {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(streamBlockHeightDesc, prometheus.GaugeValue,
+				float64(utils.MustConvStrToInt64(stream.Status.BlockNumber)), network.Network, stream.StreamId)
+			ch <- prometheus.MustNewConstMetric(streamTimestampDesc, prometheus.GaugeValue,
+				float64(stream.Status.Timestamp.UnixMilli())/1000, network.Network, stream.StreamId)
+		}
+	}
+}
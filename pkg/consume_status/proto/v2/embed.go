@@ -0,0 +1,8 @@
+package statusv2
+
+import "embed"
+
+// ProtoDir holds the proto file and its OpenAPI schema
+//
+//go:embed *.proto *.swagger.json
+var ProtoDir embed.FS
@@ -0,0 +1,135 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package statusv2
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// StatusServiceV2Client is the client API for StatusServiceV2 service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type StatusServiceV2Client interface {
+	GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*GetStatusResponse, error)
+	GetStream(ctx context.Context, in *GetStreamRequest, opts ...grpc.CallOption) (*Stream, error)
+}
+
+type statusServiceV2Client struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewStatusServiceV2Client(cc grpc.ClientConnInterface) StatusServiceV2Client {
+	return &statusServiceV2Client{cc}
+}
+
+func (c *statusServiceV2Client) GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*GetStatusResponse, error) {
+	out := new(GetStatusResponse)
+	err := c.cc.Invoke(ctx, "/consumer.status.v2.StatusServiceV2/GetStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *statusServiceV2Client) GetStream(ctx context.Context, in *GetStreamRequest, opts ...grpc.CallOption) (*Stream, error) {
+	out := new(Stream)
+	err := c.cc.Invoke(ctx, "/consumer.status.v2.StatusServiceV2/GetStream", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// StatusServiceV2Server is the server API for StatusServiceV2 service.
+// All implementations should embed UnimplementedStatusServiceV2Server
+// for forward compatibility
+type StatusServiceV2Server interface {
+	GetStatus(context.Context, *GetStatusRequest) (*GetStatusResponse, error)
+	GetStream(context.Context, *GetStreamRequest) (*Stream, error)
+}
+
+// UnimplementedStatusServiceV2Server should be embedded to have forward compatible implementations.
+type UnimplementedStatusServiceV2Server struct {
+}
+
+func (UnimplementedStatusServiceV2Server) GetStatus(context.Context, *GetStatusRequest) (*GetStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
+}
+func (UnimplementedStatusServiceV2Server) GetStream(context.Context, *GetStreamRequest) (*Stream, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStream not implemented")
+}
+
+// UnsafeStatusServiceV2Server may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to StatusServiceV2Server will
+// result in compilation errors.
+type UnsafeStatusServiceV2Server interface {
+	mustEmbedUnimplementedStatusServiceV2Server()
+}
+
+func RegisterStatusServiceV2Server(s grpc.ServiceRegistrar, srv StatusServiceV2Server) {
+	s.RegisterService(&StatusServiceV2_ServiceDesc, srv)
+}
+
+func _StatusServiceV2_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatusServiceV2Server).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/consumer.status.v2.StatusServiceV2/GetStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatusServiceV2Server).GetStatus(ctx, req.(*GetStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StatusServiceV2_GetStream_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStreamRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatusServiceV2Server).GetStream(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/consumer.status.v2.StatusServiceV2/GetStream",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatusServiceV2Server).GetStream(ctx, req.(*GetStreamRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// StatusServiceV2_ServiceDesc is the grpc.ServiceDesc for StatusServiceV2 service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var StatusServiceV2_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "consumer.status.v2.StatusServiceV2",
+	HandlerType: (*StatusServiceV2Server)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetStatus",
+			Handler:    _StatusServiceV2_GetStatus_Handler,
+		},
+		{
+			MethodName: "GetStream",
+			Handler:    _StatusServiceV2_GetStream_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/v2/status.proto",
+}
@@ -0,0 +1,138 @@
+package consume_status
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type snapshot struct {
+	Streams []snapshotStream `json:"streams"`
+}
+
+type snapshotStream struct {
+	StreamId    string    `json:"stream_id"`
+	Network     string    `json:"network"`
+	Timestamp   time.Time `json:"timestamp"`
+	BlockNumber string    `json:"block_number"`
+}
+
+// WithSnapshotFile restores statuses from the file, if it exists, and saves them to it every interval while the
+// server is running. A restored status only takes effect once its stream is registered again for the same network,
+// and is reported as stale until the stream is updated. Statuses of streams that are not registered yet are kept in
+// later snapshots, so a stream registered late doesn't lose its status; they are dropped once the stream is
+// registered for another network.
+func (s *ConsumeStatusServer) WithSnapshotFile(path string, interval time.Duration) *ConsumeStatusServer {
+	if err := s.restoreSnapshot(path); err != nil {
+		log.Printf("failed to restore status snapshot: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.backgroundTasks = append(s.backgroundTasks, func(ctx context.Context) {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				if err := s.saveSnapshot(path); err != nil {
+					log.Printf("failed to save status snapshot: %v", err)
+				}
+				return
+			case <-t.C:
+				if err := s.saveSnapshot(path); err != nil {
+					log.Printf("failed to save status snapshot: %v", err)
+				}
+			}
+		}
+	})
+	return s
+}
+
+func (s *ConsumeStatusServer) restoreSnapshot(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, stream := range snap.Streams {
+		s.restoredByStreamId[stream.StreamId] = stream
+		if _, ok := s.networkByStreamId[stream.StreamId]; ok {
+			s.applyRestoredStatus(stream.StreamId)
+		}
+	}
+	return nil
+}
+
+// applyRestoredStatus sets the restored status of a registered stream, unless the stream already has a status
+// or was restored for another network. It must be called with s.mu held.
+func (s *ConsumeStatusServer) applyRestoredStatus(streamId string) {
+	stream, ok := s.restoredByStreamId[streamId]
+	if !ok {
+		return
+	}
+	delete(s.restoredByStreamId, streamId)
+	if _, hasStatus := s.statusByStreamId[streamId]; hasStatus || s.networkByStreamId[streamId] != stream.Network {
+		return
+	}
+	s.statusByStreamId[streamId] = IndexingStatus{
+		Timestamp:   stream.Timestamp,
+		BlockNumber: stream.BlockNumber,
+	}
+	s.staleStreamIds[streamId] = struct{}{}
+}
+
+// saveSnapshot writes to a temporary file and renames it, so the snapshot is never partially written.
+func (s *ConsumeStatusServer) saveSnapshot(path string) error {
+	var snap snapshot
+	s.mu.RLock()
+	for streamId, status := range s.statusByStreamId {
+		snap.Streams = append(snap.Streams, snapshotStream{
+			StreamId:    streamId,
+			Network:     s.networkByStreamId[streamId],
+			Timestamp:   status.Timestamp,
+			BlockNumber: status.BlockNumber,
+		})
+	}
+	for _, stream := range s.restoredByStreamId {
+		snap.Streams = append(snap.Streams, stream)
+	}
+	s.mu.RUnlock()
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
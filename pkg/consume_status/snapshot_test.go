@@ -0,0 +1,62 @@
+package consume_status
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSnapshotRestoresOnlyRegisteredStreams(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	data, err := json.Marshal(snapshot{Streams: []snapshotStream{
+		{StreamId: "kept", Network: "eth", Timestamp: time.Unix(1000, 0), BlockNumber: "100"},
+		{StreamId: "retired", Network: "eth", Timestamp: time.Unix(500, 0), BlockNumber: "50"},
+		{StreamId: "moved", Network: "eth", Timestamp: time.Unix(500, 0), BlockNumber: "50"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewConsumeStatusServer().WithSnapshotFile(path, time.Hour)
+	s.RegisterStream("kept", "eth")
+	s.RegisterStream("moved", "bsc")
+
+	if len(s.statusByStreamId) != 1 || s.statusByStreamId["kept"].BlockNumber != "100" {
+		t.Errorf("statuses = %+v, want only block 100 of the kept stream", s.statusByStreamId)
+	}
+	if _, stale := s.staleStreamIds["kept"]; !stale {
+		t.Error("restored status of the kept stream is not stale")
+	}
+
+	_, reasons := s.Readiness()
+	for _, reason := range reasons {
+		if strings.Contains(reason, "retired") {
+			t.Errorf("readiness reports unregistered stream: %s", reason)
+		}
+	}
+
+	if err = s.saveSnapshot(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved snapshot
+	if err = json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	savedIds := make(map[string]string)
+	for _, stream := range saved.Streams {
+		savedIds[stream.StreamId] = stream.BlockNumber
+	}
+	if len(savedIds) != 2 || savedIds["kept"] != "100" || savedIds["retired"] != "50" {
+		t.Errorf("saved streams = %+v, want kept and the not yet registered retired", saved.Streams)
+	}
+}
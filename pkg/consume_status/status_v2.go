@@ -0,0 +1,156 @@
+package consume_status
+
+import (
+	"context"
+	statusv2 "github.com/proxima-one/indexer-utils-go/v2/pkg/consume_status/proto/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
+)
+
+// statusServerV2 implements the consumer.status.v2 StatusServiceV2
+type statusServerV2 struct {
+	s *ConsumeStatusServer
+}
+
+func (v *statusServerV2) GetStatus(_ context.Context, req *statusv2.GetStatusRequest) (*statusv2.GetStatusResponse, error) {
+	res := &statusv2.GetStatusResponse{
+		Networks:  make([]*statusv2.Network, 0),
+		Streams:   make([]*statusv2.Stream, 0),
+		Upstreams: v.s.upstreamsToProtoV2(),
+	}
+	for _, network := range v.s.networkStatuses() {
+		if req.Network != nil && network.Network != req.GetNetwork() {
+			continue
+		}
+		res.Networks = append(res.Networks, network.toProtoV2())
+		for _, stream := range network.Streams {
+			res.Streams = append(res.Streams, stream.toProtoV2(network.Network))
+		}
+	}
+	return res, nil
+}
+
+func (v *statusServerV2) GetStream(_ context.Context, req *statusv2.GetStreamRequest) (*statusv2.Stream, error) {
+	for _, network := range v.s.networkStatuses() {
+		for _, stream := range network.Streams {
+			if stream.StreamId == req.StreamId {
+				return stream.toProtoV2(network.Network), nil
+			}
+		}
+	}
+
+	v.s.mu.RLock()
+	defer v.s.mu.RUnlock()
+	network, ok := v.s.networkByStreamId[req.StreamId]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "stream %s is not registered", req.StreamId)
+	}
+	// registered, but hasn't reported anything yet
+	return &statusv2.Stream{
+		StreamId: req.StreamId,
+		Network:  network,
+		Labels:   v.s.labelsByStreamId[req.StreamId],
+		State:    statusv2.State_STATE_OK,
+	}, nil
+}
+
+func (n *networkStatus) toProtoV2() *statusv2.Network {
+	res := &statusv2.Network{
+		Network:     n.Network,
+		Aggregation: n.Aggregation,
+		StreamIds:   make([]string, 0, len(n.Streams)),
+		StreamCount: uint32(n.StreamCount),
+		State:       n.State.toProtoV2(),
+	}
+	if n.Status != nil {
+		res.Status = n.Status.toProtoV2()
+	}
+	var updatedAt time.Time
+	for _, stream := range n.Streams {
+		res.StreamIds = append(res.StreamIds, stream.StreamId)
+		if stream.UpdatedAt.After(updatedAt) {
+			updatedAt = stream.UpdatedAt
+		}
+	}
+	if !updatedAt.IsZero() {
+		res.UpdatedAt = timestamppb.New(updatedAt)
+	}
+	return res
+}
+
+func (s *streamStatus) toProtoV2(network string) *statusv2.Stream {
+	res := &statusv2.Stream{
+		StreamId:   s.StreamId,
+		Network:    network,
+		Labels:     s.Labels,
+		State:      s.State.toProtoV2(),
+		ErrorCount: uint32(s.ErrorCount),
+	}
+	if s.Status != nil {
+		res.Status = s.Status.toProtoV2()
+	}
+	if s.LastError != nil {
+		lastError := s.LastError.Error()
+		res.LastError = &lastError
+	}
+	if !s.LastErrorAt.IsZero() {
+		res.LastErrorTime = timestamppb.New(s.LastErrorAt)
+	}
+	if !s.UpdatedAt.IsZero() {
+		res.UpdatedAt = timestamppb.New(s.UpdatedAt)
+	}
+	return res
+}
+
+func (s *lagStatus) toProtoV2() *statusv2.IndexingStatus {
+	res := &statusv2.IndexingStatus{
+		Timestamp:    timestamppb.New(s.Timestamp),
+		BlockNumber:  s.BlockNumber,
+		BlocksBehind: s.BlocksBehind,
+		TimeLag:      durationpb.New(s.TimeLag),
+		IsSynced:     s.IsSynced,
+		Stale:        s.Stale,
+	}
+	if s.Head != nil {
+		headBlockNumber := s.Head.BlockNumber
+		res.HeadBlockNumber = &headBlockNumber
+		res.HeadTimestamp = timestamppb.New(s.Head.Timestamp)
+	}
+	return res
+}
+
+func (s State) toProtoV2() statusv2.State {
+	switch s {
+	case StateOK:
+		return statusv2.State_STATE_OK
+	case StateDegraded:
+		return statusv2.State_STATE_DEGRADED
+	case StateFailing:
+		return statusv2.State_STATE_FAILING
+	}
+	return statusv2.State_STATE_UNSPECIFIED
+}
+
+func (s *ConsumeStatusServer) upstreamsToProtoV2() []*statusv2.Upstream {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	res := make([]*statusv2.Upstream, 0, len(s.upstreams))
+	for _, upstream := range s.upstreams {
+		status := &statusv2.Upstream{
+			Address:   upstream.address,
+			Reachable: upstream.reachable,
+		}
+		if !upstream.lastSeen.IsZero() {
+			status.LastSeen = timestamppb.New(upstream.lastSeen)
+		}
+		if upstream.err != nil {
+			errText := upstream.err.Error()
+			status.Error = &errText
+		}
+		res = append(res, status)
+	}
+	return res
+}
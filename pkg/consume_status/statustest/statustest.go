@@ -0,0 +1,54 @@
+// Package statustest runs a ConsumeStatusServer fully in memory for tests.
+package statustest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/proxima-one/indexer-utils-go/v2/pkg/consume_status"
+	statusv2 "github.com/proxima-one/indexer-utils-go/v2/pkg/consume_status/proto/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const bufSize = 1024 * 1024
+
+// BaseURL is the address to use with the returned http.Client. Every request is routed to the in-memory gateway
+// regardless of the host.
+const BaseURL = "http://statustest"
+
+// Start serves s over in-memory listeners and returns a connected v2 gRPC client and an http.Client talking to
+// the REST gateway. Everything is torn down when ctx is done.
+func Start(ctx context.Context, s *consume_status.ConsumeStatusServer) (statusv2.StatusServiceV2Client, *http.Client, error) {
+	grpcLis := bufconn.Listen(bufSize)
+	httpLis := bufconn.Listen(bufSize)
+	s.Serve(ctx, grpcLis, httpLis)
+
+	conn, err := grpc.DialContext(
+		ctx,
+		"bufconn",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return grpcLis.DialContext(ctx)
+		}),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to dial server: %w", err)
+	}
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return httpLis.DialContext(ctx)
+			},
+		},
+	}
+	return statusv2.NewStatusServiceV2Client(conn), httpClient, nil
+}
@@ -0,0 +1,65 @@
+package statustest_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/proxima-one/indexer-utils-go/v2/pkg/consume_status"
+	statusv2 "github.com/proxima-one/indexer-utils-go/v2/pkg/consume_status/proto/v2"
+	"github.com/proxima-one/indexer-utils-go/v2/pkg/consume_status/statustest"
+)
+
+func TestStart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := consume_status.NewConsumeStatusServer()
+	s.RegisterStream("stream-1", "eth-main")
+	s.UpdateStreamStatus("stream-1", time.Now(), "100")
+
+	grpcClient, httpClient, err := statustest.Start(ctx, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("gRPC", func(t *testing.T) {
+		res, err := grpcClient.GetStatus(ctx, &statusv2.GetStatusRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Networks) != 1 || res.Networks[0].Network != "eth-main" {
+			t.Fatalf("unexpected networks %v", res.Networks)
+		}
+		if got := res.Networks[0].Status.GetBlockNumber(); got != "100" {
+			t.Errorf("block number = %q, want 100", got)
+		}
+	})
+
+	t.Run("HTTP", func(t *testing.T) {
+		res, err := httpClient.Get(statustest.BaseURL + "/api/get_status")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d, want 200", res.StatusCode)
+		}
+		var body struct {
+			Networks []struct {
+				Network string `json:"network"`
+				Status  struct {
+					BlockNumber string `json:"blockNumber"`
+				} `json:"status"`
+			} `json:"networks"`
+		}
+		if err = json.NewDecoder(res.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if len(body.Networks) != 1 || body.Networks[0].Network != "eth-main" || body.Networks[0].Status.BlockNumber != "100" {
+			t.Fatalf("unexpected response %+v", body)
+		}
+	})
+}
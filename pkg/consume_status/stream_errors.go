@@ -0,0 +1,96 @@
+package consume_status
+
+import (
+	"fmt"
+	"time"
+
+	pb "github.com/proxima-one/indexer-utils-go/v2/pkg/consume_status/internal/proto"
+	"github.com/proxima-one/indexer-utils-go/v2/pkg/utils"
+)
+
+type State int
+
+const (
+	StateOK State = iota
+	// StateDegraded means errors were reported, but the stream has progressed since the last one
+	StateDegraded
+	// StateFailing means the last reported error is not cleared and the stream has not progressed since
+	StateFailing
+)
+
+func (s State) String() string {
+	switch s {
+	case StateOK:
+		return "OK"
+	case StateDegraded:
+		return "DEGRADED"
+	case StateFailing:
+		return "FAILING"
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+func (s State) toProto() pb.State {
+	switch s {
+	case StateOK:
+		return pb.State_STATE_OK
+	case StateDegraded:
+		return pb.State_STATE_DEGRADED
+	case StateFailing:
+		return pb.State_STATE_FAILING
+	}
+	return pb.State_STATE_UNSPECIFIED
+}
+
+func stateFromProto(state pb.State) State {
+	switch state {
+	case pb.State_STATE_DEGRADED:
+		return StateDegraded
+	case pb.State_STATE_FAILING:
+		return StateFailing
+	}
+	return StateOK
+}
+
+type streamError struct {
+	LastError   error
+	LastErrorAt time.Time
+	ErrorCount  int
+}
+
+// ReportStreamError records an error of a stream, e.g. a failed attempt to process an event that will be retried.
+// Stream must be already registered
+func (s *ConsumeStatusServer) ReportStreamError(streamId string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.networkByStreamId[streamId]; !ok {
+		utils.PanicOnError(fmt.Errorf("stream %s is not registered", streamId))
+	}
+	streamErr := s.errorByStreamId[streamId]
+	streamErr.LastError = err
+	streamErr.LastErrorAt = time.Now()
+	streamErr.ErrorCount++
+	s.errorByStreamId[streamId] = streamErr
+}
+
+// ClearStreamError marks the stream as recovered. The error count is kept.
+func (s *ConsumeStatusServer) ClearStreamError(streamId string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if streamErr, ok := s.errorByStreamId[streamId]; ok {
+		streamErr.LastError = nil
+		s.errorByStreamId[streamId] = streamErr
+	}
+}
+
+// streamState must be called with s.mu held
+func (s *ConsumeStatusServer) streamState(streamId string) State {
+	streamErr, ok := s.errorByStreamId[streamId]
+	if !ok || streamErr.LastError == nil {
+		return StateOK
+	}
+	if s.updatedAtByStreamId[streamId].After(streamErr.LastErrorAt) {
+		return StateDegraded
+	}
+	return StateFailing
+}
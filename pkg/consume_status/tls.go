@@ -0,0 +1,148 @@
+package consume_status
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/proxima-one/indexer-utils-go/v2/pkg/utils"
+)
+
+// tlsReloadInterval is the minimal interval between checks of TLS files for changes
+const tlsReloadInterval = 10 * time.Second
+
+// TLSConfig points to PEM files used to serve gRPC and HTTP over TLS. Files are reloaded when they change,
+// so certificates can be rotated without restarting the server.
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile enables mTLS: clients must present a certificate signed by one of its CAs, if set
+	ClientCAFile string
+}
+
+// WithTLS serves gRPC, the gateway, health and readiness endpoints over TLS. Panics if files can't be loaded.
+func (s *ConsumeStatusServer) WithTLS(config TLSConfig) *ConsumeStatusServer {
+	reloader, err := newTLSReloader(config)
+	utils.PanicOnError(err)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tls = reloader
+	return s
+}
+
+type tlsReloader struct {
+	config TLSConfig
+
+	mu        sync.Mutex
+	current   *tls.Config
+	modTimes  []time.Time
+	checkedAt time.Time
+}
+
+func newTLSReloader(config TLSConfig) (*tlsReloader, error) {
+	r := &tlsReloader{config: config}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// serverConfig returns a config picking up the latest files on every handshake
+func (r *tlsReloader) serverConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.get(), nil
+		},
+		// http.Server.ServeTLS requires a certificate source in the top-level config
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return &r.get().Certificates[0], nil
+		},
+	}
+}
+
+func (r *tlsReloader) get() *tls.Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.checkedAt) >= tlsReloadInterval {
+		r.checkedAt = time.Now()
+		if modTimes, err := r.modTimesOfFiles(); err == nil && !equalTimes(modTimes, r.modTimes) {
+			if err := r.reloadLocked(); err != nil {
+				log.Printf("failed to reload TLS files, keeping the previous ones: %v", err)
+			}
+		}
+	}
+	return r.current
+}
+
+func (r *tlsReloader) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checkedAt = time.Now()
+	return r.reloadLocked()
+}
+
+func (r *tlsReloader) reloadLocked() error {
+	modTimes, err := r.modTimesOfFiles()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.config.CertFile, r.config.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load certificate: %w", err)
+	}
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+	if r.config.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.config.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CAs: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return errors.New("no certificates found in " + r.config.ClientCAFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	r.current = config
+	r.modTimes = modTimes
+	return nil
+}
+
+func (r *tlsReloader) modTimesOfFiles() ([]time.Time, error) {
+	files := []string{r.config.CertFile, r.config.KeyFile}
+	if r.config.ClientCAFile != "" {
+		files = append(files, r.config.ClientCAFile)
+	}
+	res := make([]time.Time, len(files))
+	for i, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = info.ModTime()
+	}
+	return res, nil
+}
+
+func equalTimes(a, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
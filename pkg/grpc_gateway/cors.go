@@ -0,0 +1,123 @@
+package grpc_gateway
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	defaultCORSMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	defaultCORSHeaders = []string{"Content-Type", "Authorization", "X-Api-Key", RequestIDHeader}
+)
+
+// CORSConfig configures cross-origin access to the API and the schema
+type CORSConfig struct {
+	// AllowedOrigins are exact origins like "https://status.example.com", wildcard subdomains like
+	// "https://*.example.com", or "*" for any origin
+	AllowedOrigins []string
+	// AllowedMethods default to GET, POST, PUT, PATCH and DELETE
+	AllowedMethods []string
+	// AllowedHeaders default to Content-Type, Authorization, X-Api-Key and X-Request-Id. "*" allows any header.
+	AllowedHeaders []string
+	// ExposedHeaders are response headers readable by the browser
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache preflight responses. Not sent if zero.
+	MaxAge time.Duration
+}
+
+// WithCORS answers preflight requests and sets CORS headers on /api and /proto/schema.json responses,
+// including the ones of services added with WithService and WithLocalService.
+func WithCORS(config CORSConfig) Option {
+	return func(o *options) {
+		o.cors = &config
+	}
+}
+
+type corsHandler struct {
+	config         CORSConfig
+	allowedMethods string
+	allowedHeaders string
+}
+
+func newCORSHandler(config CORSConfig) *corsHandler {
+	if len(config.AllowedMethods) == 0 {
+		config.AllowedMethods = defaultCORSMethods
+	}
+	if len(config.AllowedHeaders) == 0 {
+		config.AllowedHeaders = defaultCORSHeaders
+	}
+	return &corsHandler{
+		config:         config,
+		allowedMethods: strings.Join(config.AllowedMethods, ", "),
+		allowedHeaders: strings.Join(config.AllowedHeaders, ", "),
+	}
+}
+
+// handle sets CORS headers and returns true if the request was a preflight and is fully answered
+func (c *corsHandler) handle(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	h := w.Header()
+	h.Add("Vary", "Origin")
+	preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+	if preflight {
+		h.Add("Vary", "Access-Control-Request-Method")
+		h.Add("Vary", "Access-Control-Request-Headers")
+	}
+	if origin == "" || !c.originAllowed(origin) {
+		if preflight {
+			WriteError(w, r, &runtime.HTTPStatusError{
+				HTTPStatus: http.StatusForbidden,
+				Err:        status.Error(codes.PermissionDenied, "origin not allowed"),
+			})
+		}
+		return preflight
+	}
+
+	h.Set("Access-Control-Allow-Origin", origin)
+	if c.config.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+	if !preflight {
+		if len(c.config.ExposedHeaders) > 0 {
+			h.Set("Access-Control-Expose-Headers", strings.Join(c.config.ExposedHeaders, ", "))
+		}
+		return false
+	}
+
+	h.Set("Access-Control-Allow-Methods", c.allowedMethods)
+	if c.allowedHeaders == "*" {
+		// reflect the requested headers, since "*" is not honoured for credentialed requests
+		if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+			h.Set("Access-Control-Allow-Headers", requested)
+		}
+	} else {
+		h.Set("Access-Control-Allow-Headers", c.allowedHeaders)
+	}
+	if c.config.MaxAge > 0 {
+		h.Set("Access-Control-Max-Age", strconv.Itoa(int(c.config.MaxAge.Seconds())))
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+func (c *corsHandler) originAllowed(origin string) bool {
+	for _, allowed := range c.config.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+		if i := strings.Index(allowed, "*."); i >= 0 {
+			prefix, suffix := allowed[:i], allowed[i+1:]
+			if strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) && len(origin) > len(prefix)+len(suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package grpc_gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const problemContentType = "application/problem+json"
+
+// WithMarshalOptions replaces the JSON options of request and response bodies. grpc-gateway defaults to
+// protojson.MarshalOptions{EmitUnpopulated: true}; UseProtoNames and UseEnumNumbers switch to snake_case field
+// names and numeric enums. Unknown request fields are ignored either way.
+func WithMarshalOptions(marshalOptions protojson.MarshalOptions) Option {
+	return func(o *options) {
+		o.serveMuxOptions = append(o.serveMuxOptions, runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.HTTPBodyMarshaler{
+			Marshaler: &runtime.JSONPb{
+				MarshalOptions:   marshalOptions,
+				UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+			},
+		}))
+	}
+}
+
+// WithErrorHandler replaces the error body written for failed calls and unmatched routes,
+// e.g. with ProblemJSONErrorHandler. Routing errors are passed as *runtime.HTTPStatusError,
+// so the handler can keep their status, like 405 for a wrong method.
+func WithErrorHandler(errorHandler runtime.ErrorHandlerFunc) Option {
+	return func(o *options) {
+		o.serveMuxOptions = append(o.serveMuxOptions,
+			runtime.WithErrorHandler(errorHandler),
+			runtime.WithRoutingErrorHandler(func(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler,
+				w http.ResponseWriter, r *http.Request, httpStatus int) {
+
+				errorHandler(ctx, mux, marshaler, w, r, &runtime.HTTPStatusError{
+					HTTPStatus: httpStatus,
+					Err:        status.Error(routingErrorCode(httpStatus), http.StatusText(httpStatus)),
+				})
+			}),
+		)
+	}
+}
+
+type serveMuxKey struct{}
+
+// withServeMux stores the gateway mux in the request context for WriteError
+func withServeMux(r *http.Request, mux *runtime.ServeMux) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), serveMuxKey{}, mux))
+}
+
+// WriteError writes err with the error handler set by WithErrorHandler, like the errors of failed calls.
+// It is meant for middlewares added with WithAPIMiddleware; outside of the gateway it writes a plain text error.
+// err may be a gRPC status error or a *runtime.HTTPStatusError.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	if mux, ok := r.Context().Value(serveMuxKey{}).(*runtime.ServeMux); ok {
+		_, outbound := runtime.MarshalerForRequest(mux, r)
+		runtime.HTTPError(r.Context(), mux, outbound, w, r, err)
+		return
+	}
+	var statusErr *runtime.HTTPStatusError
+	if errors.As(err, &statusErr) {
+		http.Error(w, status.Convert(statusErr.Err).Message(), statusErr.HTTPStatus)
+		return
+	}
+	s := status.Convert(err)
+	http.Error(w, s.Message(), runtime.HTTPStatusFromCode(s.Code()))
+}
+
+func routingErrorCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusMethodNotAllowed:
+		return codes.Unimplemented
+	case http.StatusNotFound:
+		return codes.NotFound
+	}
+	return codes.Internal
+}
+
+// Problem is an RFC 7807 problem details object, extended with the gRPC code
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+}
+
+// ProblemJSONErrorHandler writes errors as application/problem+json (RFC 7807)
+func ProblemJSONErrorHandler(ctx context.Context, _ *runtime.ServeMux, _ runtime.Marshaler,
+	w http.ResponseWriter, r *http.Request, err error) {
+
+	httpStatus := 0
+	var statusErr *runtime.HTTPStatusError
+	if errors.As(err, &statusErr) {
+		httpStatus = statusErr.HTTPStatus
+		err = statusErr.Err
+	}
+	s := status.Convert(err)
+	if httpStatus == 0 {
+		httpStatus = runtime.HTTPStatusFromCode(s.Code())
+	}
+
+	problem := Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(httpStatus),
+		Status:   httpStatus,
+		Detail:   s.Message(),
+		Instance: r.URL.Path,
+		Code:     s.Code().String(),
+	}
+	if s.Code() == codes.Unauthenticated {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	}
+	if md, ok := runtime.ServerMetadataFromContext(ctx); ok {
+		for key, values := range md.HeaderMD {
+			for _, value := range values {
+				w.Header().Add(runtime.MetadataHeaderPrefix+key, value)
+			}
+		}
+	}
+	w.Header().Del("Trailer")
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(httpStatus)
+	_ = json.NewEncoder(w).Encode(problem)
+}
@@ -2,78 +2,363 @@ package grpc_gateway
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"google.golang.org/grpc/credentials/insecure"
 	"io/fs"
-	"mime"
+	"log"
+	"net"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"google.golang.org/grpc"
 )
 
-// getOpenAPIHandler serves an OpenAPI UI.
-// Adapted from https://github.com/philips/grpc-gateway-example/blob/a269bcb5931ca92be0ceae6130ac27ae89582ecc/cmd/serve.go#L63
-func getOpenAPIHandler() http.Handler {
-	err := mime.AddExtensionType(".svg", "image/svg+xml")
+func getProtoFileHandler(schema []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(schema)
+	})
+}
+
+const defaultShutdownTimeout = 5 * time.Second
+
+// Option configures the gRPC-Gateway.
+type Option func(*options)
+
+type options struct {
+	grpcAddress     string
+	dialOptions     []grpc.DialOption
+	serveMuxOptions []runtime.ServeMuxOption
+	tlsConfig       *tls.Config
+	services        []service
+	basePath        string
+	shutdownTimeout time.Duration
+	middlewares     []Middleware
+	apiMiddlewares  []Middleware
+	cors            *CORSConfig
+	swaggerUI       SwaggerUIConfig
+	openAPI3Path    string
+}
+
+// RegisterHandlerServerFunc registers handlers calling the server implementation directly,
+// e.g. a closure over one of the generated Register*HandlerServer functions.
+type RegisterHandlerServerFunc func(context.Context, *runtime.ServeMux) error
+
+// service is registered either with a client connection (registerServiceHandler) or in-process (registerHandlerServer)
+type service struct {
+	protoFileFolder        fs.FS
+	protoFileName          string
+	registerServiceHandler func(context.Context, *runtime.ServeMux, *grpc.ClientConn) error
+	registerHandlerServer  RegisterHandlerServerFunc
+}
+
+// WithDialAddress sets the address dialled for services added with WithService.
+// Only needed with NewLocalHandler, ServeLocal and RunLocal, which don't take an address.
+func WithDialAddress(grpcAddress string) Option {
+	return func(o *options) {
+		o.grpcAddress = grpcAddress
+	}
+}
+
+// WithDialOptions appends options used when dialling the gRPC server,
+// e.g. grpc.WithContextDialer to reach it over a unix socket or bufconn,
+// or grpc.WithTransportCredentials to replace the default insecure credentials.
+func WithDialOptions(dialOptions ...grpc.DialOption) Option {
+	return func(o *options) {
+		o.dialOptions = append(o.dialOptions, dialOptions...)
+	}
+}
+
+// WithServeMuxOptions appends options of the gateway mux, e.g. runtime.WithIncomingHeaderMatcher.
+func WithServeMuxOptions(serveMuxOptions ...runtime.ServeMuxOption) Option {
+	return func(o *options) {
+		o.serveMuxOptions = append(o.serveMuxOptions, serveMuxOptions...)
+	}
+}
+
+// WithTLSConfig makes Run and Serve serve HTTPS. The config must provide a certificate.
+func WithTLSConfig(tlsConfig *tls.Config) Option {
+	return func(o *options) {
+		o.tlsConfig = tlsConfig
+	}
+}
+
+// WithBasePath serves the handler under the prefix, e.g. "/status" serves the API under /status/api.
+// Requests outside the prefix are answered with 404.
+func WithBasePath(basePath string) Option {
+	return func(o *options) {
+		o.basePath = strings.TrimSuffix(basePath, "/")
+	}
+}
+
+// WithShutdownTimeout limits how long Run and Serve wait for active requests once ctx is done. 5 seconds by default.
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.shutdownTimeout = timeout
+	}
+}
+
+// WithService exposes one more service served by the same gRPC server. Its OpenAPI document is merged
+// into the schema served at /proto/schema.json.
+func WithService(protoFileFolder fs.FS, protoFileName string,
+	registerServiceHandler func(context.Context, *runtime.ServeMux, *grpc.ClientConn) error) Option {
+
+	return func(o *options) {
+		o.services = append(o.services, service{
+			protoFileFolder:        protoFileFolder,
+			protoFileName:          protoFileName,
+			registerServiceHandler: registerServiceHandler,
+		})
+	}
+}
+
+// WithLocalService exposes one more service implemented in the same process, without dialling it. Its OpenAPI
+// document is merged into the schema served at /proto/schema.json. Like all Register*HandlerServer functions,
+// it bypasses gRPC interceptors and doesn't support streaming; see WithAPIMiddleware.
+func WithLocalService(protoFileFolder fs.FS, protoFileName string, registerHandlerServer RegisterHandlerServerFunc) Option {
+	return func(o *options) {
+		o.services = append(o.services, service{
+			protoFileFolder:       protoFileFolder,
+			protoFileName:         protoFileName,
+			registerHandlerServer: registerHandlerServer,
+		})
+	}
+}
+
+// Run runs the gRPC-Gateway, dialling the provided address. It shuts down gracefully once ctx is done.
+func Run(ctx context.Context, grpcAddress string, httpPort int, protoFileFolder fs.FS, protoFileName string,
+	registerServiceHandler func(context.Context, *runtime.ServeMux, *grpc.ClientConn) error, opts ...Option) error {
+
+	lis, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%d", httpPort))
 	if err != nil {
-		return nil
+		return fmt.Errorf("failed to listen: %w", err)
 	}
-	// Use subdirectory in embedded files
-	subFS, err := fs.Sub(OpenAPI, "OpenAPI")
+	return Serve(ctx, grpcAddress, lis, protoFileFolder, protoFileName, registerServiceHandler, opts...)
+}
+
+// Serve runs the gRPC-Gateway on the provided listener, dialling the provided address.
+// Once ctx is done, it stops accepting connections and waits for active requests, returning nil.
+func Serve(ctx context.Context, grpcAddress string, lis net.Listener, protoFileFolder fs.FS, protoFileName string,
+	registerServiceHandler func(context.Context, *runtime.ServeMux, *grpc.ClientConn) error, opts ...Option) error {
+
+	handler, err := NewHandler(ctx, grpcAddress, protoFileFolder, protoFileName, registerServiceHandler, opts...)
 	if err != nil {
-		panic("couldn't create sub filesystem: " + err.Error())
+		_ = lis.Close()
+		return err
 	}
-	return http.FileServer(http.FS(subFS))
+	return serveHandler(ctx, lis, handler, opts)
 }
 
-func getProtoFileHandler(folder fs.FS) http.Handler {
-	err := mime.AddExtensionType(".svg", "image/svg+xml")
+// RunLocal runs the gRPC-Gateway calling an in-process server implementation. It shuts down gracefully once ctx is done.
+func RunLocal(ctx context.Context, httpPort int, protoFileFolder fs.FS, protoFileName string,
+	registerHandlerServer RegisterHandlerServerFunc, opts ...Option) error {
+
+	lis, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%d", httpPort))
 	if err != nil {
-		return nil
+		return fmt.Errorf("failed to listen: %w", err)
 	}
-	return http.FileServer(http.FS(folder))
+	return ServeLocal(ctx, lis, protoFileFolder, protoFileName, registerHandlerServer, opts...)
 }
 
-// Run runs the gRPC-Gateway, dialling the provided address.
-func Run(ctx context.Context, grpcAddress string, httpPort int, protoFileFolder fs.FS, protoFileName string,
-	registerServiceHandler func(context.Context, *runtime.ServeMux, *grpc.ClientConn) error) error {
+// ServeLocal runs the gRPC-Gateway calling an in-process server implementation on the provided listener.
+// Once ctx is done, it stops accepting connections and waits for active requests, returning nil.
+func ServeLocal(ctx context.Context, lis net.Listener, protoFileFolder fs.FS, protoFileName string,
+	registerHandlerServer RegisterHandlerServerFunc, opts ...Option) error {
 
-	conn, err := grpc.DialContext(
-		ctx,
-		grpcAddress,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithBlock(),
-	)
+	handler, err := NewLocalHandler(ctx, protoFileFolder, protoFileName, registerHandlerServer, opts...)
 	if err != nil {
-		return fmt.Errorf("failed to dial server: %w", err)
+		_ = lis.Close()
+		return err
+	}
+	return serveHandler(ctx, lis, handler, opts)
+}
+
+func serveHandler(ctx context.Context, lis net.Listener, handler http.Handler, opts []Option) error {
+	o := options{shutdownTimeout: defaultShutdownTimeout}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	gwServer := &http.Server{Handler: handler, TLSConfig: o.tlsConfig}
+	shutdownErr := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), o.shutdownTimeout)
+		defer cancel()
+		shutdownErr <- gwServer.Shutdown(shutdownCtx)
+	}()
+
+	var err error
+	if o.tlsConfig != nil {
+		err = gwServer.ServeTLS(lis, "", "")
+	} else {
+		err = gwServer.Serve(lis)
+	}
+	if !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("serving gRPC-Gateway server error: %w", err)
+	}
+	if err = <-shutdownErr; err != nil {
+		return fmt.Errorf("shutting down gRPC-Gateway server: %w", err)
+	}
+	return nil
+}
+
+// NewHandler dials the provided address and returns a handler serving the gateway under /api,
+// the (merged) proto schema at /proto/schema.json, its OpenAPI 3 conversion if enabled by WithOpenAPI3
+// and the OpenAPI UI as configured by WithSwaggerUI, all under the base path if one is set.
+// It can be mounted into any router or server.
+func NewHandler(ctx context.Context, grpcAddress string, protoFileFolder fs.FS, protoFileName string,
+	registerServiceHandler func(context.Context, *runtime.ServeMux, *grpc.ClientConn) error, opts ...Option) (http.Handler, error) {
+
+	return newHandler(ctx, grpcAddress, service{
+		protoFileFolder:        protoFileFolder,
+		protoFileName:          protoFileName,
+		registerServiceHandler: registerServiceHandler,
+	}, opts)
+}
+
+// NewLocalHandler is NewHandler calling an in-process server implementation instead of dialling it.
+// Services added with WithService are still dialled, using the address set by WithDialAddress.
+func NewLocalHandler(ctx context.Context, protoFileFolder fs.FS, protoFileName string,
+	registerHandlerServer RegisterHandlerServerFunc, opts ...Option) (http.Handler, error) {
+
+	return newHandler(ctx, "", service{
+		protoFileFolder:       protoFileFolder,
+		protoFileName:         protoFileName,
+		registerHandlerServer: registerHandlerServer,
+	}, opts)
+}
+
+func newHandler(ctx context.Context, grpcAddress string, primary service, opts []Option) (_ http.Handler, err error) {
+	o := options{services: []service{primary}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if grpcAddress == "" {
+		grpcAddress = o.grpcAddress
 	}
 
-	gwmux := runtime.NewServeMux()
-	err = registerServiceHandler(ctx, gwmux, conn)
+	schema, err := loadOpenAPIDocument(o.services, o.basePath)
 	if err != nil {
-		return fmt.Errorf("failed to register openapi: %w", err)
-	}
-
-	openAPIHandler := getOpenAPIHandler()
-	protoFileHandler := getProtoFileHandler(protoFileFolder)
-
-	gatewayAddr := fmt.Sprintf("0.0.0.0:%d", httpPort)
-	gwServer := &http.Server{
-		Addr: gatewayAddr,
-		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if strings.HasPrefix(r.URL.Path, "/api") {
-				gwmux.ServeHTTP(w, r)
-			} else if r.URL.Path == "/proto/schema.json" {
-				r.URL.Path = "/" + protoFileName
-				protoFileHandler.ServeHTTP(w, r)
-			} else {
-				openAPIHandler.ServeHTTP(w, r)
+		return nil, err
+	}
+	var openAPI3Schema []byte
+	if o.openAPI3Path != "" {
+		if openAPI3Schema, err = convertToOpenAPI3(schema); err != nil {
+			return nil, fmt.Errorf("failed to convert openapi document to OpenAPI 3: %w", err)
+		}
+	}
+
+	var conn *grpc.ClientConn
+	gwmux := runtime.NewServeMux(append([]runtime.ServeMuxOption{
+		runtime.WithMetadata(recordRoute),
+		runtime.WithMetadata(forwardRequestID),
+	}, o.serveMuxOptions...)...)
+	for _, svc := range o.services {
+		if svc.registerHandlerServer != nil {
+			err = svc.registerHandlerServer(ctx, gwmux)
+		} else {
+			if conn == nil {
+				if conn, err = dial(ctx, grpcAddress, o.dialOptions); err != nil {
+					return nil, err
+				}
+				// like the generated Register*HandlerFromEndpoint, the handler owns the connection until ctx is done
+				defer closeOnDone(ctx, conn, &err)
 			}
+			err = svc.registerServiceHandler(ctx, gwmux, conn)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to register openapi: %w", err)
+		}
+	}
+
+	openAPIHandler, err := getOpenAPIHandler(o.swaggerUI, o.basePath)
+	if err != nil {
+		return nil, err
+	}
+	apiHandler := applyMiddlewares(gwmux, o.apiMiddlewares)
+	protoFileHandler := getProtoFileHandler(schema)
+	openAPI3Handler := getProtoFileHandler(openAPI3Schema)
+	var cors *corsHandler
+	if o.cors != nil {
+		cors = newCORSHandler(*o.cors)
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		isSchema := r.URL.Path == "/proto/schema.json" || o.openAPI3Path != "" && r.URL.Path == o.openAPI3Path
+		isAPI := strings.HasPrefix(r.URL.Path, "/api") || isSchema
+		if isAPI {
+			r = withServeMux(r, gwmux)
+		}
+		if cors != nil && isAPI && cors.handle(w, r) {
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, "/api") {
+			apiHandler.ServeHTTP(w, r)
+		} else if r.URL.Path == "/proto/schema.json" {
+			setRoute(r.Context(), r.URL.Path)
+			protoFileHandler.ServeHTTP(w, r)
+		} else if isSchema {
+			setRoute(r.Context(), r.URL.Path)
+			openAPI3Handler.ServeHTTP(w, r)
+		} else {
+			setRoute(r.Context(), "openapi_ui")
+			openAPIHandler.ServeHTTP(w, r)
+		}
+
+	})
+	if o.basePath == "" {
+		return applyMiddlewares(handler, o.middlewares), nil
+	}
+	stripped := http.StripPrefix(o.basePath, handler)
+	return applyMiddlewares(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == o.basePath {
+			// the OpenAPI UI loads the schema relatively to its own path
+			http.Redirect(w, r, o.basePath+"/", http.StatusMovedPermanently)
+			return
+		}
+		if !strings.HasPrefix(r.URL.Path, o.basePath+"/") {
+			http.NotFound(w, r)
+			return
+		}
+		stripped.ServeHTTP(w, r)
+	}), o.middlewares), nil
+}
 
-		}),
+// closeOnDone closes conn once ctx is done, or right away if newHandler failed
+func closeOnDone(ctx context.Context, conn *grpc.ClientConn, err *error) {
+	if *err != nil {
+		if closeErr := conn.Close(); closeErr != nil {
+			log.Printf("failed to close gRPC connection to %s: %v", conn.Target(), closeErr)
+		}
+		return
 	}
+	go func() {
+		<-ctx.Done()
+		if closeErr := conn.Close(); closeErr != nil {
+			log.Printf("failed to close gRPC connection to %s: %v", conn.Target(), closeErr)
+		}
+	}()
+}
 
-	return fmt.Errorf("serving gRPC-Gateway server error: %w", gwServer.ListenAndServe())
+func dial(ctx context.Context, grpcAddress string, dialOptions []grpc.DialOption) (*grpc.ClientConn, error) {
+	if grpcAddress == "" {
+		return nil, errors.New("failed to dial server: no gRPC address, set it with WithDialAddress")
+	}
+	conn, err := grpc.DialContext(
+		ctx,
+		grpcAddress,
+		append([]grpc.DialOption{
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithBlock(),
+		}, dialOptions...)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial server: %w", err)
+	}
+	return conn, nil
 }
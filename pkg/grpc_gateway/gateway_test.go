@@ -0,0 +1,68 @@
+package grpc_gateway
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	statusv2 "github.com/proxima-one/indexer-utils-go/v2/pkg/consume_status/proto/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+func TestNewHandlerClosesConnection(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	grpcServer := grpc.NewServer()
+	statusv2.RegisterStatusServiceV2Server(grpcServer, &streamServer{})
+	go func() { _ = grpcServer.Serve(lis) }()
+	t.Cleanup(grpcServer.Stop)
+
+	waitShutdown := func(t *testing.T, conn *grpc.ClientConn) {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		for state := conn.GetState(); state != connectivity.Shutdown; state = conn.GetState() {
+			if !conn.WaitForStateChange(ctx, state) {
+				t.Fatalf("connection not closed, state %s", state)
+			}
+		}
+	}
+
+	t.Run("context done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		var conn *grpc.ClientConn
+		_, err := NewHandler(ctx, lis.Addr().String(), statusv2.ProtoDir, statusV2Schema,
+			func(ctx context.Context, mux *runtime.ServeMux, c *grpc.ClientConn) error {
+				conn = c
+				return statusv2.RegisterStatusServiceV2Handler(ctx, mux, c)
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state := conn.GetState(); state == connectivity.Shutdown {
+			t.Fatal("connection closed before the context is done")
+		}
+		cancel()
+		waitShutdown(t, conn)
+	})
+
+	t.Run("registration fails", func(t *testing.T) {
+		var conn *grpc.ClientConn
+		_, err := NewHandler(context.Background(), lis.Addr().String(), statusv2.ProtoDir, statusV2Schema,
+			func(_ context.Context, _ *runtime.ServeMux, c *grpc.ClientConn) error {
+				conn = c
+				return errors.New("failed")
+			})
+		if err == nil {
+			t.Fatal("expected the registration error")
+		}
+		waitShutdown(t, conn)
+	})
+}
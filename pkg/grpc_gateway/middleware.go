@@ -0,0 +1,304 @@
+package grpc_gateway
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/metadata"
+)
+
+const RequestIDHeader = "X-Request-Id"
+
+// Middleware wraps the gateway handler
+type Middleware func(http.Handler) http.Handler
+
+// WithMiddleware wraps the handler with middlewares. The first one is the outermost, i.e. sees the request first.
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(o *options) {
+		o.middlewares = append(o.middlewares, middlewares...)
+	}
+}
+
+// WithAPIMiddleware wraps only the /api handler, with the base path stripped and after CORS. Calls of services added
+// with WithLocalService or NewLocalHandler bypass gRPC interceptors, so this is where to check them, e.g. for auth.
+// The first middleware is the outermost.
+func WithAPIMiddleware(middlewares ...Middleware) Option {
+	return func(o *options) {
+		o.apiMiddlewares = append(o.apiMiddlewares, middlewares...)
+	}
+}
+
+func applyMiddlewares(handler http.Handler, middlewares []Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// Logging logs every request with its status, size and duration. log.Default() is used if logger is nil.
+func Logging(logger *log.Logger) Middleware {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := newStatusRecorder(w)
+			next.ServeHTTP(rec, r)
+			line := r.Method + " " + r.URL.RequestURI() + " " + strconv.Itoa(rec.status) +
+				" " + strconv.Itoa(rec.size) + "B " + time.Since(start).String()
+			if id := RequestIDFromContext(r.Context()); id != "" {
+				line += " request_id=" + id
+			}
+			logger.Println(line)
+		})
+	}
+}
+
+// Recovery responds with 500 instead of dropping the connection when a handler panics, logging the stack.
+// log.Default() is used if logger is nil.
+func Recovery(logger *log.Logger) Middleware {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := newStatusRecorder(w)
+			defer func() {
+				err := recover()
+				if err == nil {
+					return
+				}
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+				logger.Printf("panic serving %s %s: %v\n%s", r.Method, r.URL.Path, err, debug.Stack())
+				if !rec.wroteHeader {
+					http.Error(rec, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+			}()
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
+
+type requestIDKey struct{}
+
+// RequestID reuses the X-Request-Id header of the request or generates one. The id is set on the response,
+// stored in the request context and forwarded to gRPC as x-request-id metadata.
+func RequestID() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if id == "" {
+				id = newRequestID()
+				r.Header.Set(RequestIDHeader, id)
+			}
+			w.Header().Set(RequestIDHeader, id)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+		})
+	}
+}
+
+// RequestIDFromContext returns the id set by the RequestID middleware
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
+// forwardRequestID passes the request id to gRPC handlers as x-request-id metadata
+func forwardRequestID(ctx context.Context, _ *http.Request) metadata.MD {
+	if id := RequestIDFromContext(ctx); id != "" {
+		return metadata.Pairs(strings.ToLower(RequestIDHeader), id)
+	}
+	return nil
+}
+
+// Gzip compresses responses of clients accepting gzip
+func Gzip() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			if !acceptsGzip(r) || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+			gw := &gzipResponseWriter{ResponseWriter: w}
+			defer gw.close()
+			next.ServeHTTP(gw, r)
+		})
+	}
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		if strings.TrimSpace(strings.SplitN(encoding, ";", 2)[0]) == "gzip" {
+			return true
+		}
+	}
+	return false
+}
+
+// gzipResponseWriter starts compressing on the first write, unless the response is already encoded
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	writer      *gzip.Writer
+	wroteHeader bool
+	passthrough bool
+}
+
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" || code == http.StatusNoContent || code == http.StatusNotModified {
+		w.passthrough = true
+	} else {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		w.writer = gzip.NewWriter(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.passthrough {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.writer.Write(b)
+}
+
+func (w *gzipResponseWriter) Flush() {
+	if w.writer != nil {
+		_ = w.writer.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *gzipResponseWriter) close() {
+	if w.writer != nil {
+		_ = w.writer.Close()
+	}
+}
+
+// Prometheus counts requests and observes their duration by method, route and status code. Routes are the
+// gateway path patterns (e.g. /api/v2/streams/{stream_id}), so path parameters don't blow up the cardinality.
+func Prometheus(registerer prometheus.Registerer) (Middleware, error) {
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_http_requests_total",
+		Help: "Number of HTTP requests served by the gRPC-Gateway",
+	}, []string{"method", "route", "code"})
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gateway_http_request_duration_seconds",
+		Help:    "Duration of HTTP requests served by the gRPC-Gateway",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+	for _, collector := range []prometheus.Collector{requests, duration} {
+		if err := registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			route := &routeHolder{route: "unmatched"}
+			rec := newStatusRecorder(w)
+			next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), routeKey{}, route)))
+			requests.WithLabelValues(r.Method, route.route, strconv.Itoa(rec.status)).Inc()
+			duration.WithLabelValues(r.Method, route.route).Observe(time.Since(start).Seconds())
+		})
+	}, nil
+}
+
+type routeKey struct{}
+
+// routeHolder is filled by the handler with the route serving the request
+type routeHolder struct {
+	route string
+}
+
+func setRoute(ctx context.Context, route string) {
+	if holder, ok := ctx.Value(routeKey{}).(*routeHolder); ok {
+		holder.route = route
+	}
+}
+
+// recordRoute is a metadata annotator, the only gateway hook seeing the matched path pattern
+func recordRoute(ctx context.Context, _ *http.Request) metadata.MD {
+	if pattern, ok := runtime.HTTPPathPattern(ctx); ok {
+		setRoute(ctx, pattern)
+	}
+	return nil
+}
+
+// statusRecorder remembers the status and size of the response
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	size        int
+	wroteHeader bool
+}
+
+func newStatusRecorder(w http.ResponseWriter) *statusRecorder {
+	return &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.status = code
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.size += n
+	return n, err
+}
+
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := r.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("response writer doesn't support hijacking")
+}
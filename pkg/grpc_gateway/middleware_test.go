@@ -0,0 +1,230 @@
+package grpc_gateway
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/prometheus/client_golang/prometheus"
+	statusv2 "github.com/proxima-one/indexer-utils-go/v2/pkg/consume_status/proto/v2"
+	"google.golang.org/grpc/metadata"
+)
+
+const statusV2Schema = "status.swagger.json"
+
+// streamServer serves GetStream, remembering the metadata of the last call
+type streamServer struct {
+	statusv2.UnimplementedStatusServiceV2Server
+	md metadata.MD
+}
+
+func (s *streamServer) GetStream(ctx context.Context, req *statusv2.GetStreamRequest) (*statusv2.Stream, error) {
+	s.md, _ = metadata.FromIncomingContext(ctx)
+	return &statusv2.Stream{StreamId: req.StreamId}, nil
+}
+
+func newStreamHandler(t *testing.T, server *streamServer, opts ...Option) http.Handler {
+	t.Helper()
+	handler, err := NewLocalHandler(context.Background(), statusv2.ProtoDir, statusV2Schema,
+		func(ctx context.Context, mux *runtime.ServeMux) error {
+			return statusv2.RegisterStatusServiceV2HandlerServer(ctx, mux, server)
+		}, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return handler
+}
+
+func TestLogging(t *testing.T) {
+	var buf bytes.Buffer
+	handler := newStreamHandler(t, &streamServer{}, WithMiddleware(RequestID(), Logging(log.New(&buf, "", 0))))
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/streams/stream-1?verbose=true", nil)
+	req.Header.Set(RequestIDHeader, "request-1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+	line := strings.TrimSuffix(buf.String(), "\n")
+	prefix := "GET /api/v2/streams/stream-1?verbose=true 200 " + strconv.Itoa(rec.Body.Len()) + "B "
+	if !strings.HasPrefix(line, prefix) || !strings.HasSuffix(line, " request_id=request-1") {
+		t.Errorf("logged %q, want %q, the duration and request_id=request-1", line, prefix)
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	recovery := Recovery(log.New(io.Discard, "", 0))
+
+	t.Run("panic", func(t *testing.T) {
+		handler := recovery(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic("boom")
+		}))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v2/status", nil))
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("status = %d, want 500", rec.Code)
+		}
+	})
+
+	t.Run("panic after writing the header", func(t *testing.T) {
+		handler := recovery(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+			panic("boom")
+		}))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v2/status", nil))
+		if rec.Code != http.StatusAccepted {
+			t.Errorf("status = %d, want the written 202", rec.Code)
+		}
+	})
+
+	t.Run("abort handler", func(t *testing.T) {
+		handler := recovery(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic(http.ErrAbortHandler)
+		}))
+		defer func() {
+			if err := recover(); err != http.ErrAbortHandler {
+				t.Errorf("recovered %v, want http.ErrAbortHandler", err)
+			}
+		}()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v2/status", nil))
+	})
+}
+
+func TestRequestID(t *testing.T) {
+	t.Run("reused", func(t *testing.T) {
+		server := &streamServer{}
+		handler := newStreamHandler(t, server, WithMiddleware(RequestID()))
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/streams/stream-1", nil)
+		req.Header.Set(RequestIDHeader, "request-1")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+		}
+		if id := rec.Header().Get(RequestIDHeader); id != "request-1" {
+			t.Errorf("response id = %q, want request-1", id)
+		}
+		if ids := server.md.Get("x-request-id"); len(ids) != 1 || ids[0] != "request-1" {
+			t.Errorf("x-request-id metadata = %v, want [request-1]", ids)
+		}
+	})
+
+	t.Run("generated", func(t *testing.T) {
+		var fromContext string
+		handler := RequestID()(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			fromContext = RequestIDFromContext(r.Context())
+		}))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v2/status", nil))
+		id := rec.Header().Get(RequestIDHeader)
+		if id == "" || id != fromContext {
+			t.Errorf("response id = %q, context id = %q, want the same generated id", id, fromContext)
+		}
+	})
+}
+
+func TestGzip(t *testing.T) {
+	const body = `{"networks":[]}`
+	tests := []struct {
+		name         string
+		method       string
+		status       int
+		encoding     string
+		wantEncoding string
+	}{
+		{"compressed", http.MethodGet, http.StatusOK, "", "gzip"},
+		{"head", http.MethodHead, http.StatusOK, "", ""},
+		{"no content", http.MethodGet, http.StatusNoContent, "", ""},
+		{"not modified", http.MethodGet, http.StatusNotModified, "", ""},
+		{"already encoded", http.MethodGet, http.StatusOK, "br", "br"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := Gzip()(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if tt.encoding != "" {
+					w.Header().Set("Content-Encoding", tt.encoding)
+				}
+				w.WriteHeader(tt.status)
+				if tt.status == http.StatusOK {
+					_, _ = io.WriteString(w, body)
+				}
+			}))
+			req := httptest.NewRequest(tt.method, "/api/v2/status", nil)
+			req.Header.Set("Accept-Encoding", "gzip, deflate")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if encoding := rec.Header().Get("Content-Encoding"); encoding != tt.wantEncoding {
+				t.Fatalf("Content-Encoding = %q, want %q", encoding, tt.wantEncoding)
+			}
+			if tt.wantEncoding != "gzip" {
+				if tt.status == http.StatusOK && rec.Body.String() != body {
+					t.Errorf("body = %q, want it unchanged", rec.Body)
+				}
+				return
+			}
+			reader, err := gzip.NewReader(rec.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decompressed, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(decompressed) != body {
+				t.Errorf("decompressed body = %q, want %q", decompressed, body)
+			}
+		})
+	}
+}
+
+func TestPrometheus(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	metrics, err := Prometheus(registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := newStreamHandler(t, &streamServer{}, WithMiddleware(metrics))
+	for _, path := range []string{"/api/v2/streams/stream-1", "/api/v2/streams/stream-2", "/proto/schema.json"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: status = %d, body %s", path, rec.Code, rec.Body)
+		}
+	}
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := make(map[string]float64)
+	for _, family := range families {
+		if family.GetName() != "gateway_http_requests_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "route" {
+					counts[label.GetValue()] += metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	if counts["/api/v2/streams/{stream_id}"] != 2 {
+		t.Errorf("requests by route = %v, want 2 for /api/v2/streams/{stream_id}", counts)
+	}
+	if counts["/proto/schema.json"] != 1 {
+		t.Errorf("requests by route = %v, want 1 for /proto/schema.json", counts)
+	}
+}
@@ -0,0 +1,144 @@
+package grpc_gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"strings"
+)
+
+// sharedSections are OpenAPI v2 objects whose entries are merged by key. Documents may share an entry only if they
+// define it identically, like rpcStatus and protobufAny generated for every service.
+var sharedSections = []string{"definitions", "securityDefinitions", "responses", "parameters"}
+
+type openAPIDocument struct {
+	name string
+	data []byte
+}
+
+// loadOpenAPIDocument reads the schema of every service, merging them if there are several.
+// A non-empty basePath is set as the document basePath, so the OpenAPI UI calls the mounted API.
+func loadOpenAPIDocument(services []service, basePath string) ([]byte, error) {
+	docs := make([]openAPIDocument, 0, len(services))
+	for _, svc := range services {
+		data, err := fs.ReadFile(svc.protoFileFolder, svc.protoFileName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read openapi document %s: %w", svc.protoFileName, err)
+		}
+		docs = append(docs, openAPIDocument{name: svc.protoFileName, data: data})
+	}
+	if len(docs) == 1 && basePath == "" {
+		return docs[0].data, nil
+	}
+	return mergeOpenAPIDocuments(docs, basePath)
+}
+
+// mergeOpenAPIDocuments merges swagger 2.0 documents into the first one, overriding its basePath if set.
+// It fails if documents define the same operation, operationId or tag, or different entries under the same key.
+func mergeOpenAPIDocuments(docs []openAPIDocument, basePath string) ([]byte, error) {
+	var res map[string]interface{}
+	m := openAPIMerger{definedBy: make(map[string]string)}
+	for i, doc := range docs {
+		var parsed map[string]interface{}
+		if err := json.Unmarshal(doc.data, &parsed); err != nil {
+			return nil, fmt.Errorf("failed to parse openapi document %s: %w", doc.name, err)
+		}
+		if i == 0 {
+			res = parsed
+		}
+		if err := m.merge(res, parsed, doc.name, i == 0); err != nil {
+			return nil, err
+		}
+	}
+	if basePath != "" {
+		res["basePath"] = basePath
+	}
+	return json.MarshalIndent(res, "", "  ")
+}
+
+type openAPIMerger struct {
+	// definedBy maps operations, operationIds, tags and shared entries to the document defining them
+	definedBy map[string]string
+}
+
+// claim records that the document defines what, failing if another document already does
+func (m *openAPIMerger) claim(what, docName string) error {
+	if other, ok := m.definedBy[what]; ok {
+		return fmt.Errorf("openapi documents %s and %s both define %s", other, docName, what)
+	}
+	m.definedBy[what] = docName
+	return nil
+}
+
+func (m *openAPIMerger) merge(res, doc map[string]interface{}, docName string, first bool) error {
+	paths := section(res, "paths")
+	for path, item := range section(doc, "paths") {
+		operations, _ := item.(map[string]interface{})
+		mergedOperations, ok := paths[path].(map[string]interface{})
+		if !ok {
+			mergedOperations = make(map[string]interface{})
+			paths[path] = mergedOperations
+		}
+		for method, operation := range operations {
+			if err := m.claim("operation "+strings.ToUpper(method)+" "+path, docName); err != nil {
+				return err
+			}
+			fields, _ := operation.(map[string]interface{})
+			if id, ok := fields["operationId"].(string); ok {
+				if err := m.claim("operationId "+id, docName); err != nil {
+					return err
+				}
+			}
+			mergedOperations[method] = operation
+		}
+	}
+
+	for _, name := range sharedSections {
+		merged := section(res, name)
+		for key, entry := range section(doc, name) {
+			what := name + " " + key
+			if existing, ok := merged[key]; ok && !first {
+				if !reflect.DeepEqual(existing, entry) {
+					return fmt.Errorf("openapi documents %s and %s define different %s", m.definedBy[what], docName, what)
+				}
+				continue
+			}
+			m.definedBy[what] = docName
+			merged[key] = entry
+		}
+	}
+
+	for _, tag := range tags(doc) {
+		if err := m.claim("tag "+tagName(tag), docName); err != nil {
+			return err
+		}
+		if !first {
+			res["tags"] = append(tags(res), tag)
+		}
+	}
+	return nil
+}
+
+// section returns the object of the document under key, adding an empty one if missing
+func section(doc map[string]interface{}, key string) map[string]interface{} {
+	res, ok := doc[key].(map[string]interface{})
+	if !ok {
+		res = make(map[string]interface{})
+		doc[key] = res
+	}
+	return res
+}
+
+func tags(doc map[string]interface{}) []interface{} {
+	res, _ := doc["tags"].([]interface{})
+	return res
+}
+
+func tagName(tag interface{}) string {
+	if m, ok := tag.(map[string]interface{}); ok {
+		name, _ := m["name"].(string)
+		return name
+	}
+	return ""
+}
@@ -0,0 +1,390 @@
+package grpc_gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	openAPI3Version     = "3.0.3"
+	defaultOpenAPI3Path = "/proto/schema.openapi3.json"
+)
+
+// parameterSchemaFields are swagger 2.0 parameter and header fields which moved to the schema in OpenAPI 3
+var parameterSchemaFields = []string{
+	"type", "format", "items", "enum", "default", "maximum", "exclusiveMaximum", "minimum", "exclusiveMinimum",
+	"maxLength", "minLength", "pattern", "maxItems", "minItems", "uniqueItems", "multipleOf",
+}
+
+// swagger 2.0 operation keys with no OpenAPI 3 counterpart at the same place
+var convertedOperationFields = map[string]bool{
+	"parameters": true, "responses": true, "consumes": true, "produces": true, "schemes": true,
+}
+
+var refPrefixes = map[string]string{
+	"#/definitions/": "#/components/schemas/",
+	"#/parameters/":  "#/components/parameters/",
+	"#/responses/":   "#/components/responses/",
+}
+
+// WithOpenAPI3 also serves the schema converted to OpenAPI 3.0 at the path, /proto/schema.openapi3.json if empty.
+// The conversion runs once, when the handler is created.
+func WithOpenAPI3(path string) Option {
+	return func(o *options) {
+		if path == "" {
+			path = defaultOpenAPI3Path
+		}
+		o.openAPI3Path = path
+	}
+}
+
+// convertToOpenAPI3 converts a swagger 2.0 document, as generated by protoc-gen-openapiv2, to OpenAPI 3.0
+func convertToOpenAPI3(swagger []byte) ([]byte, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(swagger, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse openapi document: %w", err)
+	}
+	if version, _ := doc["swagger"].(string); version != "2.0" {
+		return nil, fmt.Errorf("unsupported openapi document version %q", version)
+	}
+
+	res := map[string]interface{}{"openapi": openAPI3Version}
+	for key, value := range doc {
+		switch key {
+		case "info", "tags", "externalDocs", "security":
+			res[key] = value
+		default:
+			if strings.HasPrefix(key, "x-") {
+				res[key] = value
+			}
+		}
+	}
+	if servers := openAPI3Servers(doc); len(servers) > 0 {
+		res["servers"] = servers
+	}
+
+	consumes := mediaTypes(doc["consumes"], "application/json")
+	produces := mediaTypes(doc["produces"], "application/json")
+
+	components := map[string]interface{}{}
+	if definitions, ok := doc["definitions"].(map[string]interface{}); ok {
+		components["schemas"] = definitions
+	}
+	if definitions, ok := doc["securityDefinitions"].(map[string]interface{}); ok {
+		schemes := make(map[string]interface{}, len(definitions))
+		for name, definition := range definitions {
+			schemes[name] = convertSecurityScheme(asMap(definition))
+		}
+		components["securitySchemes"] = schemes
+	}
+	if parameters, ok := doc["parameters"].(map[string]interface{}); ok {
+		converted := make(map[string]interface{})
+		for name, parameter := range parameters {
+			// body parameters have no component equivalent and are only used by reference, which is rare
+			if in, _ := asMap(parameter)["in"].(string); in != "body" && in != "formData" {
+				converted[name] = convertParameter(asMap(parameter))
+			}
+		}
+		components["parameters"] = converted
+	}
+	if responses, ok := doc["responses"].(map[string]interface{}); ok {
+		converted := make(map[string]interface{}, len(responses))
+		for name, response := range responses {
+			converted[name] = convertResponse(asMap(response), produces)
+		}
+		components["responses"] = converted
+	}
+	if len(components) > 0 {
+		res["components"] = components
+	}
+
+	paths := map[string]interface{}{}
+	for path, item := range asMap(doc["paths"]) {
+		convertedItem := map[string]interface{}{}
+		for key, value := range asMap(item) {
+			if key == "parameters" {
+				convertedItem[key] = convertParameters(value)
+			} else if isOperation(key) {
+				convertedItem[key] = convertOperation(asMap(value), consumes, produces)
+			} else {
+				convertedItem[key] = value
+			}
+		}
+		paths[path] = convertedItem
+	}
+	res["paths"] = paths
+
+	return json.MarshalIndent(convertSchemas(res), "", "  ")
+}
+
+func openAPI3Servers(doc map[string]interface{}) []interface{} {
+	basePath, _ := doc["basePath"].(string)
+	host, _ := doc["host"].(string)
+	if host == "" {
+		if basePath == "" {
+			return nil
+		}
+		return []interface{}{map[string]interface{}{"url": basePath}}
+	}
+	schemes := mediaTypes(doc["schemes"], "https")
+	servers := make([]interface{}, 0, len(schemes))
+	for _, scheme := range schemes {
+		servers = append(servers, map[string]interface{}{"url": scheme + "://" + host + basePath})
+	}
+	return servers
+}
+
+func isOperation(key string) bool {
+	switch strings.ToUpper(key) {
+	case http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete,
+		http.MethodOptions, http.MethodHead, http.MethodPatch:
+		return true
+	}
+	return false
+}
+
+func convertOperation(op map[string]interface{}, consumes, produces []string) map[string]interface{} {
+	res := make(map[string]interface{}, len(op))
+	for key, value := range op {
+		if !convertedOperationFields[key] {
+			res[key] = value
+		}
+	}
+	consumes = mediaTypes(op["consumes"], consumes...)
+	produces = mediaTypes(op["produces"], produces...)
+
+	var parameters []interface{}
+	formProperties := map[string]interface{}{}
+	var formRequired []interface{}
+	formContentType := "application/x-www-form-urlencoded"
+	for _, value := range asSlice(op["parameters"]) {
+		parameter := asMap(value)
+		switch parameter["in"] {
+		case "body":
+			requestBody := map[string]interface{}{"content": mediaContent(consumes, parameter["schema"])}
+			copyFields(requestBody, parameter, "description", "required")
+			copyExtensions(requestBody, parameter)
+			res["requestBody"] = requestBody
+		case "formData":
+			name, _ := parameter["name"].(string)
+			schema := parameterSchema(parameter)
+			if schema["type"] == "file" {
+				schema["type"], schema["format"] = "string", "binary"
+				formContentType = "multipart/form-data"
+			}
+			copyFields(schema, parameter, "description")
+			formProperties[name] = schema
+			if required, _ := parameter["required"].(bool); required {
+				formRequired = append(formRequired, name)
+			}
+		default:
+			parameters = append(parameters, convertParameter(parameter))
+		}
+	}
+	if len(formProperties) > 0 {
+		schema := map[string]interface{}{"type": "object", "properties": formProperties}
+		if len(formRequired) > 0 {
+			schema["required"] = formRequired
+		}
+		res["requestBody"] = map[string]interface{}{
+			"content": map[string]interface{}{formContentType: map[string]interface{}{"schema": schema}},
+		}
+	}
+	if len(parameters) > 0 {
+		res["parameters"] = parameters
+	}
+
+	responses := map[string]interface{}{}
+	for code, response := range asMap(op["responses"]) {
+		responses[code] = convertResponse(asMap(response), produces)
+	}
+	res["responses"] = responses
+	return res
+}
+
+func convertParameters(value interface{}) []interface{} {
+	var res []interface{}
+	for _, parameter := range asSlice(value) {
+		res = append(res, convertParameter(asMap(parameter)))
+	}
+	return res
+}
+
+// convertParameter converts a non-body parameter
+func convertParameter(parameter map[string]interface{}) map[string]interface{} {
+	if ref, ok := parameter["$ref"]; ok {
+		return map[string]interface{}{"$ref": ref}
+	}
+	res := map[string]interface{}{}
+	copyFields(res, parameter, "name", "in", "description", "required", "allowEmptyValue")
+	copyExtensions(res, parameter)
+	if res["in"] == "path" {
+		res["required"] = true
+	}
+
+	schema := parameterSchema(parameter)
+	if schema["type"] == "file" {
+		schema["type"], schema["format"] = "string", "binary"
+	}
+	res["schema"] = schema
+
+	switch parameter["collectionFormat"] {
+	case "csv":
+		res["style"], res["explode"] = "form", false
+		if res["in"] == "path" || res["in"] == "header" {
+			res["style"] = "simple"
+		}
+	case "multi":
+		res["style"], res["explode"] = "form", true
+	case "ssv":
+		res["style"] = "spaceDelimited"
+	case "pipes":
+		res["style"] = "pipeDelimited"
+	}
+	return res
+}
+
+func parameterSchema(parameter map[string]interface{}) map[string]interface{} {
+	schema := map[string]interface{}{}
+	copyFields(schema, parameter, parameterSchemaFields...)
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		schema["items"] = parameterSchema(items)
+	}
+	return schema
+}
+
+func convertResponse(response map[string]interface{}, produces []string) map[string]interface{} {
+	if ref, ok := response["$ref"]; ok {
+		return map[string]interface{}{"$ref": ref}
+	}
+	res := map[string]interface{}{"description": ""}
+	copyFields(res, response, "description")
+	copyExtensions(res, response)
+	if schema, ok := response["schema"]; ok {
+		content := mediaContent(produces, schema)
+		for mediaType, example := range asMap(response["examples"]) {
+			if media, ok := content[mediaType].(map[string]interface{}); ok {
+				media["example"] = example
+			}
+		}
+		res["content"] = content
+	}
+	if headers, ok := response["headers"].(map[string]interface{}); ok {
+		converted := make(map[string]interface{}, len(headers))
+		for name, value := range headers {
+			header := asMap(value)
+			convertedHeader := map[string]interface{}{"schema": parameterSchema(header)}
+			copyFields(convertedHeader, header, "description")
+			converted[name] = convertedHeader
+		}
+		res["headers"] = converted
+	}
+	return res
+}
+
+func convertSecurityScheme(scheme map[string]interface{}) map[string]interface{} {
+	res := map[string]interface{}{}
+	copyFields(res, scheme, "description")
+	copyExtensions(res, scheme)
+	switch scheme["type"] {
+	case "basic":
+		res["type"], res["scheme"] = "http", "basic"
+	case "apiKey":
+		copyFields(res, scheme, "type", "name", "in")
+	case "oauth2":
+		res["type"] = "oauth2"
+		flow := map[string]interface{}{"scopes": map[string]interface{}{}}
+		copyFields(flow, scheme, "authorizationUrl", "tokenUrl", "scopes")
+		flowName := map[interface{}]string{
+			"implicit":    "implicit",
+			"password":    "password",
+			"application": "clientCredentials",
+			"accessCode":  "authorizationCode",
+		}[scheme["flow"]]
+		res["flows"] = map[string]interface{}{flowName: flow}
+	default:
+		copyFields(res, scheme, "type")
+	}
+	return res
+}
+
+// convertSchemas rewrites references and swagger 2.0 schema extensions of the whole document
+func convertSchemas(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			v[key] = convertSchemas(child)
+		}
+		if ref, ok := v["$ref"].(string); ok {
+			for from, to := range refPrefixes {
+				if strings.HasPrefix(ref, from) {
+					v["$ref"] = to + strings.TrimPrefix(ref, from)
+				}
+			}
+		}
+		if nullable, ok := v["x-nullable"]; ok {
+			v["nullable"] = nullable
+			delete(v, "x-nullable")
+		}
+		if discriminator, ok := v["discriminator"].(string); ok {
+			v["discriminator"] = map[string]interface{}{"propertyName": discriminator}
+		}
+		return v
+	case []interface{}:
+		for i, child := range v {
+			v[i] = convertSchemas(child)
+		}
+		return v
+	}
+	return value
+}
+
+func mediaContent(mediaTypes []string, schema interface{}) map[string]interface{} {
+	content := make(map[string]interface{}, len(mediaTypes))
+	for _, mediaType := range mediaTypes {
+		content[mediaType] = map[string]interface{}{"schema": schema}
+	}
+	return content
+}
+
+// mediaTypes returns the strings of the value, or defaults if there are none
+func mediaTypes(value interface{}, defaults ...string) []string {
+	var res []string
+	for _, item := range asSlice(value) {
+		if s, ok := item.(string); ok {
+			res = append(res, s)
+		}
+	}
+	if len(res) == 0 {
+		return defaults
+	}
+	return res
+}
+
+func copyFields(dst, src map[string]interface{}, keys ...string) {
+	for _, key := range keys {
+		if value, ok := src[key]; ok {
+			dst[key] = value
+		}
+	}
+}
+
+func copyExtensions(dst, src map[string]interface{}) {
+	for key, value := range src {
+		if strings.HasPrefix(key, "x-") {
+			dst[key] = value
+		}
+	}
+}
+
+func asMap(value interface{}) map[string]interface{} {
+	m, _ := value.(map[string]interface{})
+	return m
+}
+
+func asSlice(value interface{}) []interface{} {
+	s, _ := value.([]interface{})
+	return s
+}
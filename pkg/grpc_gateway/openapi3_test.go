@@ -0,0 +1,102 @@
+package grpc_gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestConvertToOpenAPI3(t *testing.T) {
+	merged, err := mergeOpenAPIDocuments(readOpenAPIDocuments(t, statusSchemas...), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		swagger []byte
+	}{
+		{"status", readOpenAPIDocuments(t, statusSchemas[0])[0].data},
+		{"merged status, v2 and admin", merged},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			converted, err := convertToOpenAPI3(tt.swagger)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkOpenAPI3Document(t, converted)
+		})
+	}
+}
+
+func TestServeOpenAPI3(t *testing.T) {
+	handler := newStreamHandler(t, &streamServer{}, WithOpenAPI3(""))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, defaultOpenAPI3Path, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+	checkOpenAPI3Document(t, rec.Body.Bytes())
+}
+
+func checkOpenAPI3Document(t *testing.T, data []byte) {
+	t.Helper()
+	if strings.Contains(string(data), "#/definitions/") {
+		t.Error("document still refers to #/definitions/")
+	}
+
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			OperationID string `json:"operationId"`
+			Parameters  []struct {
+				Name   string      `json:"name"`
+				In     string      `json:"in"`
+				Type   string      `json:"type"`
+				Schema interface{} `json:"schema"`
+			} `json:"parameters"`
+			Responses map[string]struct {
+				Schema  interface{}            `json:"schema"`
+				Content map[string]interface{} `json:"content"`
+			} `json:"responses"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenAPI != openAPI3Version {
+		t.Errorf("openapi = %q, want %s", doc.OpenAPI, openAPI3Version)
+	}
+	if len(doc.Components.Schemas) == 0 {
+		t.Error("no components.schemas")
+	}
+
+	operationIDs := make(map[string]bool)
+	for path, operations := range doc.Paths {
+		for method, operation := range operations {
+			if operationIDs[operation.OperationID] {
+				t.Errorf("duplicate operationId %s", operation.OperationID)
+			}
+			operationIDs[operation.OperationID] = true
+
+			for _, parameter := range operation.Parameters {
+				if parameter.Schema == nil || parameter.Type != "" {
+					t.Errorf("%s %s: %s parameter %s has no schema or keeps its type", method, path, parameter.In, parameter.Name)
+				}
+			}
+			for code, response := range operation.Responses {
+				if len(response.Content) == 0 || response.Schema != nil {
+					t.Errorf("%s %s: response %s has no content or keeps its schema", method, path, code)
+				}
+			}
+		}
+	}
+	if len(operationIDs) == 0 {
+		t.Error("no operations")
+	}
+}
@@ -0,0 +1,107 @@
+package grpc_gateway
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+var statusSchemas = []string{
+	"../consume_status/internal/proto/indexing_status.swagger.json",
+	"../consume_status/proto/v2/status.swagger.json",
+	"../consume_status/internal/proto/admin.swagger.json",
+}
+
+func readOpenAPIDocuments(t *testing.T, paths ...string) []openAPIDocument {
+	t.Helper()
+	res := make([]openAPIDocument, len(paths))
+	for i, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res[i] = openAPIDocument{name: path, data: data}
+	}
+	return res
+}
+
+func TestMergeOpenAPIDocuments(t *testing.T) {
+	merged, err := mergeOpenAPIDocuments(readOpenAPIDocuments(t, statusSchemas...), "/status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		BasePath string                            `json:"basePath"`
+		Paths    map[string]map[string]interface{} `json:"paths"`
+		Tags     []struct {
+			Name string `json:"name"`
+		} `json:"tags"`
+	}
+	if err = json.Unmarshal(merged, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.BasePath != "/status" {
+		t.Errorf("basePath = %q, want /status", doc.BasePath)
+	}
+	for _, path := range []string{"/api/get_status", "/api/v2/status", "/api/v2/streams/{streamId}"} {
+		if _, ok := doc.Paths[path]["get"]; !ok {
+			t.Errorf("missing GET %s", path)
+		}
+	}
+	if len(doc.Tags) != 3 {
+		t.Errorf("tags = %+v, want one per service", doc.Tags)
+	}
+}
+
+func TestMergeOpenAPIDocumentsConflicts(t *testing.T) {
+	tests := []struct {
+		name    string
+		second  string
+		wantErr string
+	}{
+		{
+			name:   "identical shared definition",
+			second: `{"paths": {"/b": {"get": {"operationId": "B_Get"}}}, "definitions": {"rpcStatus": {"type": "object"}}}`,
+		},
+		{
+			name:    "redefined operation",
+			second:  `{"paths": {"/a": {"get": {"operationId": "B_Get"}}}}`,
+			wantErr: "operation GET /a",
+		},
+		{
+			name:    "reused operationId",
+			second:  `{"paths": {"/b": {"get": {"operationId": "A_Get"}}}}`,
+			wantErr: "operationId A_Get",
+		},
+		{
+			name:    "different definition",
+			second:  `{"definitions": {"rpcStatus": {"type": "string"}}}`,
+			wantErr: "definitions rpcStatus",
+		},
+		{
+			name:    "duplicate tag",
+			second:  `{"tags": [{"name": "A"}]}`,
+			wantErr: "tag A",
+		},
+	}
+	first := openAPIDocument{name: "a.json", data: []byte(`{
+		"tags": [{"name": "A"}],
+		"paths": {"/a": {"get": {"operationId": "A_Get"}}},
+		"definitions": {"rpcStatus": {"type": "object"}}
+	}`)}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := mergeOpenAPIDocuments([]openAPIDocument{first, {name: "b.json", data: []byte(tt.second)}}, "")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want one about %s", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package grpc_gateway
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+const swaggerUITemplate = "index.html.tmpl"
+
+// SwaggerUIConfig configures the embedded OpenAPI UI
+type SwaggerUIConfig struct {
+	// Disabled turns the UI off, e.g. in production. The schema is still served.
+	Disabled bool
+	// Prefix the UI is mounted under, e.g. "/docs". If empty, the UI is served on every path
+	// that is not taken by the API or the schema.
+	Prefix string
+	// Title of the page, "Swagger UI" by default
+	Title string
+	// SchemaURL loaded by the UI. By default, ./proto/schema.json, or /proto/schema.json under the base path
+	// if the UI is mounted under a prefix.
+	SchemaURL string
+	// Version is appended to the title if set
+	Version string
+}
+
+// WithSwaggerUI configures the OpenAPI UI, which is served on every non-API path by default.
+func WithSwaggerUI(config SwaggerUIConfig) Option {
+	return func(o *options) {
+		o.swaggerUI = config
+	}
+}
+
+// WithoutSwaggerUI disables the OpenAPI UI.
+func WithoutSwaggerUI() Option {
+	return func(o *options) {
+		o.swaggerUI.Disabled = true
+	}
+}
+
+// getOpenAPIHandler serves an OpenAPI UI.
+// Adapted from https://github.com/philips/grpc-gateway-example/blob/a269bcb5931ca92be0ceae6130ac27ae89582ecc/cmd/serve.go#L63
+func getOpenAPIHandler(config SwaggerUIConfig, basePath string) (http.Handler, error) {
+	if config.Disabled {
+		return http.NotFoundHandler(), nil
+	}
+	if config.Title == "" {
+		config.Title = "Swagger UI"
+	}
+	prefix := strings.TrimSuffix(config.Prefix, "/")
+	if config.SchemaURL == "" && prefix == "" {
+		// relative, so it works wherever the handler is mounted
+		config.SchemaURL = "./proto/schema.json"
+	} else if config.SchemaURL == "" {
+		config.SchemaURL = basePath + "/proto/schema.json"
+	}
+
+	err := mime.AddExtensionType(".svg", "image/svg+xml")
+	if err != nil {
+		return nil, err
+	}
+	// Use subdirectory in embedded files
+	subFS, err := fs.Sub(OpenAPI, "OpenAPI")
+	if err != nil {
+		panic("couldn't create sub filesystem: " + err.Error())
+	}
+	tmpl, err := template.ParseFS(subFS, swaggerUITemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse swagger ui template: %w", err)
+	}
+	var index bytes.Buffer
+	if err = tmpl.Execute(&index, config); err != nil {
+		return nil, fmt.Errorf("failed to render swagger ui template: %w", err)
+	}
+
+	fileServer := http.FileServer(http.FS(subFS))
+	ui := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/", "/index.html":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write(index.Bytes())
+		case "/" + swaggerUITemplate:
+			http.NotFound(w, r)
+		default:
+			fileServer.ServeHTTP(w, r)
+		}
+	})
+	if prefix == "" {
+		return ui, nil
+	}
+
+	stripped := http.StripPrefix(prefix, ui)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == prefix:
+			// relative asset URLs of the page need the trailing slash
+			http.Redirect(w, r, basePath+prefix+"/", http.StatusMovedPermanently)
+		case strings.HasPrefix(r.URL.Path, prefix+"/"):
+			stripped.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	}), nil
+}